@@ -24,7 +24,9 @@ interface ApiKeyData {
 
 // Server response interfaces
 interface ApiKeyResponse {
+  // key is masked by the server; hasKey tells whether a key is saved
   key: string
+  hasKey?: boolean
   isValid: boolean
   models: string[]
   selectedModel: string
@@ -87,14 +89,15 @@ export default function BoardsSettings() {
       
       console.log(`Processing ${provider} data:`, data);
       
-      // Check for invalid key formats - if the key is masked or contains 
-      // invalid characters like bullets, we'll clear it to force re-entry
+      // Saved keys come back masked and stay on the server. Sending the
+      // masked key back when saving keeps the saved key.
+      // Any other key in an invalid format is cleared to force re-entry
       let keyToUse = data.key || '';
-      const isInvalidFormat = 
+      const isInvalidFormat = !data.hasKey && (
         keyToUse.includes('•') || 
         keyToUse.includes('●') || 
         keyToUse.includes('Bearer') || 
-        keyToUse.length < 10;
+        keyToUse.length < 10);
         
       if (isInvalidFormat) {
         console.log(`Found invalid key format for ${provider}, clearing key to force re-entry`);
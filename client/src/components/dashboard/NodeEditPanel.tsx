@@ -8,9 +8,7 @@ import { setEditingNode, updateNodes, updateEdges } from '@/store/boardSlice'
 import { RichTextEditor } from './nodes/RichTextEditor'
 import { ParentNodeTrace } from './nodes/ParentNodeTrace'
 import { authService } from '@/services/auth'
-import axios from 'axios'
 import { Node, Edge, Position } from 'reactflow'
-import { getPlainText } from '@/lib/utils/text-helpers'
 
 // Define AI providers
 type ApiProvider = 'openai' | 'claude' | 'klusterai';
@@ -36,20 +34,6 @@ interface NodeContent {
   isHtml?: boolean;
 }
 
-interface NodeData {
-  label?: string;
-  content?: NodeContent;
-  [key: string]: unknown;
-}
-
-// Define the node context type
-interface NodeContextData {
-  asciiTree: string;
-  nodeList: Array<{ id: string; label: string; content: string }>;
-  boardTitle: string;
-  currentNode: NodeData | null;
-}
-
 // Add counters for each type of ID to ensure uniqueness
 let nodeCounter = 0;
 let edgeCounter = 0;
@@ -78,113 +62,6 @@ const formatCompactText = (text: string): string => {
   return `<p style="color: #4b5563; font-style: italic;">${chunks.join('<br />')}</p>`;
 };
 
-/**
- * Custom hook to build node context tree
- * IMPORTANT: This hook must be called in a React component at the top level,
- * we pass in boardTitle instead of using useSelector inside to avoid invalid hook calls.
- */
-function useNodeTreeBuilder(nodeId: string, nodes: Node[], edges: Edge[], boardTitle: string) {
-  // Create and memoize node context
-  const [nodeContext, setNodeContext] = useState<NodeContextData>({
-    asciiTree: '',
-    nodeList: [],
-    boardTitle,
-    currentNode: null
-  });
-
-  // Build the tree whenever nodes, edges, or the selected node changes
-  useEffect(() => {
-    // Build tree function
-    const buildTree = () => {
-      // Track visited nodes
-      const visited = new Set<string>();
-      const nodeList: Array<{ id: string; label: string; content: string }> = [];
-      let asciiTree = '';
-      
-      // Collect connected nodes data
-      const collectNodes = (id: string, prefix = '', isLast = true) => {
-        if (!id || visited.has(id)) return;
-        visited.add(id);
-        
-        // Find this node
-        const node = nodes.find(n => n.id === id);
-        if (!node) return;
-        
-        // Add to list
-        nodeList.push({
-          id: node.id,
-          label: node.data.label || 'Unnamed Node',
-          content: getPlainText(node.data.content?.text || '')
-        });
-        
-        // Add to tree with formatting
-        const label = node.data.label || 'Unnamed Node';
-        asciiTree += `${prefix}${isLast ? '└── ' : '├── '}${label}${id === nodeId ? ' (current)' : ''}\n`;
-        
-        // Get children
-        const children = edges
-          .filter(edge => edge.source === id)
-          .map(edge => edge.target);
-        
-        // Add children with proper formatting
-        children.forEach((childId, index) => {
-          collectNodes(
-            childId, 
-            prefix + (isLast ? '    ' : '│   '), 
-            index === children.length - 1
-          );
-        });
-      };
-      
-      // Find root nodes (no parents)
-      const rootNodes = nodes.filter(node => 
-        !edges.some(edge => edge.target === node.id)
-      ).map(node => node.id);
-      
-      // Build the tree
-      if (rootNodes.length > 0) {
-        rootNodes.forEach((rootId, index) => {
-          collectNodes(rootId, '', index === rootNodes.length - 1);
-        });
-      } else if (nodeId) {
-        // No root nodes, start with selected node
-        collectNodes(nodeId, '', true);
-      }
-      
-      // If tree is empty, at least show the current node
-      if (asciiTree === '' && nodeId) {
-        const node = nodes.find(n => n.id === nodeId);
-        if (node) {
-          asciiTree = `└── ${node.data.label || 'Unnamed Node'} (current)\n`;
-          
-          // Make sure we have this node in the list
-          if (!nodeList.some(n => n.id === nodeId)) {
-            nodeList.push({
-              id: node.id,
-              label: node.data.label || 'Unnamed Node',
-              content: getPlainText(node.data.content?.text || '')
-            });
-          }
-        }
-      }
-      
-      return {
-        asciiTree,
-        nodeList,
-        boardTitle,
-        currentNode: nodes.find(n => n.id === nodeId)?.data || null
-      };
-    };
-    
-    // Build and update
-    const result = buildTree();
-    console.log("Built node tree:", result.asciiTree);
-    setNodeContext(result);
-  }, [nodeId, nodes, edges, boardTitle]);
-  
-  return nodeContext;
-}
-
 interface NodeEditPanelProps {
   nodeId: string
 }
@@ -198,10 +75,9 @@ const NodeEditPanel = ({ nodeId }: NodeEditPanelProps) => {
   const dispatch = useDispatch()
   const nodes = useSelector((state: RootState) => state.board.nodes)
   const edges = useSelector((state: RootState) => state.board.edges)
+  const boardId = useSelector((state: RootState) => state.board.boardId)
   const panelRef = useRef<HTMLDivElement>(null)
   
-  // Use our custom hook to build the node tree
-  const nodeContext = useNodeTreeBuilder(nodeId, nodes, edges, useSelector((state: RootState) => state.board.boardName) || 'Untitled Board');
   
   // Find the selected node
   const selectedNode = nodes.find(node => node.id === nodeId)
@@ -228,7 +104,6 @@ const NodeEditPanel = ({ nodeId }: NodeEditPanelProps) => {
   });
   // Which provider is currently selected in this chat
   const [selectedProvider, setSelectedProvider] = useState<ApiProvider>('openai');
-  const [apiKey, setApiKey] = useState<string>('');
   const [model, setModel] = useState<string>('');
   
   // State for editing JSON suggestions
@@ -1325,91 +1200,16 @@ const NodeEditPanel = ({ nodeId }: NodeEditPanelProps) => {
     setChatError(null);
     
     try {
-      // Get context data
-      const treeText = nodeContext.asciiTree || '';
-      const nodeDetails = nodeContext.nodeList || [];
-      const boardTitle = nodeContext.boardTitle || 'Untitled Board';
-      const currentNode = nodeContext.currentNode || { label: 'Unnamed Node' };
-      
-      // Structure context messages properly for the API
-      const contextMsgs: {role: string; content: string}[] = [];
-      
-      // 1. Add current node details
-      const currentNodeName = currentNode.label || 'Unnamed Node';
-      const currentNodeContent = currentNode.content?.text || '';
-      
-      contextMsgs.push({ 
-        role: 'user' as const, 
-        content: `Current node: ${currentNodeName}\nDetails: ${getPlainText(currentNodeContent)}` as const
-      });
-      
-      // 2. Add board title
-      contextMsgs.push({ 
-        role: 'user', 
-        content: `Board title: ${boardTitle}` 
-      });
-      
-      // 3. Add the complete node tree
-      if (treeText.trim() !== '') {
-        contextMsgs.push({ 
-          role: 'user', 
-          content: `Node Context Tree:\n${treeText}` 
-        });
-      } else {
-        console.warn('ASCII tree is empty, using fallback structure');
-        contextMsgs.push({ 
-          role: 'user', 
-          content: `Node Context Tree: ${currentNodeName}` 
-        });
-      }
-      
-      // 4. Add all node details
-      if (nodeDetails.length > 0) {
-        const detailsText = nodeDetails
-          .map(n => `${n.label || 'Unnamed'}: ${n.content || 'No content'}`)
-          .join('\n');
-        contextMsgs.push({ 
-          role: 'user', 
-          content: `Node Details:\n${detailsText}` 
-        });
-      }
-      
-      // 5. Add specific instruction for JSON format to enable branching
-      contextMsgs.push({
-        role: 'user',
-        content: `Please provide your response in a valid JSON format with categories as keys and arrays of concept objects as values. Each concept object should include a "title" and a "reason" explaining why it's important. Optionally, concepts can have "sub_branches" for deeper exploration. For example: 
-{
-  "Category 1": [
-    {
-      "title": "Concept 1",
-      "reason": "Why this concept is important",
-      "sub_branches": [
-        {"title": "Sub-concept A", "reason": "Explanation for this sub-concept"}
-      ]
-    }
-  ],
-  "Category 2": [
-    {"title": "Concept 2", "reason": "Justification for this concept"}
-  ]
-}
-This structure will be used to automatically create a mind map with meaningful content.`
+      // The server builds the prompt from the saved board and calls the
+      // provider with the key saved in Settings, so keys never reach the browser
+      const result = await authService.post('/api/ai/brainstorm', {
+        boardId,
+        nodeId,
+        message: chatInput,
+        provider: selectedProvider,
+        model,
       });
-      
-      // Log the context being sent
-      console.log('Sending context to API:', contextMsgs);
-      
-      // Send the request with structured context
-      const resp = await axios.post(
-        '/api/airequest',
-        { 
-          model, 
-          message: chatInput, 
-          context: contextMsgs, 
-          apiKey,
-          provider: selectedProvider
-        },
-        { headers: { 'Content-Type': 'application/json' } }
-      );
+      const resp = { data: result.data?.suggestions ?? {} };
       
       // Handle the response
       if (resp.data && Object.keys(resp.data).length > 0) {
@@ -1570,7 +1370,6 @@ This structure will be used to automatically create a mind map with meaningful c
           ? 'openai'
           : PROVIDERS.find(p => updated[p].isValid) || 'openai';
         setSelectedProvider(defaultProv);
-        setApiKey(updated[defaultProv].key);
         setModel(updated[defaultProv].selectedModel);
       } catch (err) {
         console.error('Failed to load API keys', err);
@@ -1685,7 +1484,6 @@ This structure will be used to automatically create a mind map with meaningful c
             onChange={(e) => {
               const p = e.target.value as ApiProvider;
               setSelectedProvider(p);
-              setApiKey(providers[p].key);
               setModel(providers[p].selectedModel);
             }}
           >
@@ -0,0 +1,275 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"saas-server/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrAILimitExceeded is wrapped by every AILimitError
+var ErrAILimitExceeded = errors.New("AI usage limit exceeded")
+
+// AI limit names used in AILimitError
+const (
+	AILimitRequests = "requests"
+	AILimitTokens   = "tokens"
+)
+
+// meteredFilter limits usage totals to a user's requests that count towards
+// their monthly limits. Listing models costs nothing and is not limited.
+const meteredFilter = "user_id = $3 AND operation = ANY($4)"
+
+// meteredOperations are the operations counted by meteredFilter
+var meteredOperations = pq.StringArray{models.AIOperationBrainstorm, models.AIOperationBrainstormStream}
+
+// DefaultAILimits apply to users whose subscription variant has no row in
+// ai_plan_limits, and to users without an active subscription
+var DefaultAILimits = models.AILimits{
+	MaxRequests: 500,
+	MaxTokens:   2000000,
+}
+
+// AILimitError is returned when a user has used up one of their plan's
+// monthly AI limits
+type AILimitError struct {
+	Limit    string
+	Max      int64
+	Used     int64
+	ResetsAt time.Time
+}
+
+func (e *AILimitError) Error() string {
+	return fmt.Sprintf("monthly AI %s limit reached: %d used, limit is %d", e.Limit, e.Used, e.Max)
+}
+
+func (e *AILimitError) Unwrap() error {
+	return ErrAILimitExceeded
+}
+
+// AIUsageMonth returns the start of the calendar month containing t and the
+// start of the next one, in UTC. Usage and limits are counted per month.
+func AIUsageMonth(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// RecordAIUsage stores one AI provider request
+func (db *DB) RecordAIUsage(usage *models.AIUsage) error {
+	query := `
+		INSERT INTO ai_usage (user_id, provider, model, operation, prompt_tokens, completion_tokens, latency_ms, outcome)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	return db.QueryRow(query,
+		usage.UserID, usage.Provider, usage.Model, usage.Operation,
+		usage.PromptTokens, usage.CompletionTokens, usage.LatencyMs, usage.Outcome,
+	).Scan(&usage.ID, &usage.CreatedAt)
+}
+
+// GetAILimits looks up the monthly AI limits of the user's active subscription variant
+func (db *DB) GetAILimits(userID string) (models.AILimits, error) {
+	query := `
+		SELECT l.max_requests_per_month, l.max_tokens_per_month
+		FROM subscriptions s
+		JOIN ai_plan_limits l ON l.variant_id = s.variant_id
+		WHERE s.user_id = $1 AND s.status = 'active'
+		ORDER BY s.created_at DESC
+		LIMIT 1
+	`
+
+	var limits models.AILimits
+	err := db.QueryRow(query, userID).Scan(&limits.MaxRequests, &limits.MaxTokens)
+	if err == sql.ErrNoRows {
+		return DefaultAILimits, nil
+	}
+	if err != nil {
+		return models.AILimits{}, err
+	}
+
+	return limits, nil
+}
+
+// CheckAILimits returns an *AILimitError if the user has reached their
+// plan's request or token limit for the current month
+func (db *DB) CheckAILimits(userID string) error {
+	limits, err := db.GetAILimits(userID)
+	if err != nil {
+		return err
+	}
+	if limits.MaxRequests == 0 && limits.MaxTokens == 0 {
+		return nil
+	}
+
+	start, end := AIUsageMonth(time.Now())
+	usage, err := db.aiUsageTotals(meteredFilter, start, end, userID, meteredOperations)
+	if err != nil {
+		return err
+	}
+
+	if limits.MaxRequests > 0 && usage.Requests >= limits.MaxRequests {
+		return &AILimitError{Limit: AILimitRequests, Max: int64(limits.MaxRequests), Used: int64(usage.Requests), ResetsAt: end}
+	}
+	if limits.MaxTokens > 0 && usage.TotalTokens >= limits.MaxTokens {
+		return &AILimitError{Limit: AILimitTokens, Max: limits.MaxTokens, Used: usage.TotalTokens, ResetsAt: end}
+	}
+	return nil
+}
+
+// GetAIMonthlyUsage retrieves a user's AI usage in the calendar month
+// containing month, with their plan's limits. Only the requests that count
+// towards the limits are included.
+func (db *DB) GetAIMonthlyUsage(userID string, month time.Time) (*models.AIMonthlyUsage, error) {
+	start, end := AIUsageMonth(month)
+
+	limits, err := db.GetAILimits(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := db.aiUsageTotals(meteredFilter, start, end, userID, meteredOperations)
+	if err != nil {
+		return nil, err
+	}
+
+	byModel, err := db.aiModelUsage(meteredFilter, start, end, userID, meteredOperations)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AIMonthlyUsage{
+		Month:       start.Format("2006-01"),
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Limits:      limits,
+		Usage:       usage,
+		ByModel:     byModel,
+	}, nil
+}
+
+// GetAIUsageReport aggregates the AI usage of every user in the calendar
+// month containing month. Users are ordered by tokens used, heaviest first.
+func (db *DB) GetAIUsageReport(month time.Time) (*models.AIUsageReport, error) {
+	start, end := AIUsageMonth(month)
+
+	totals, err := db.aiUsageTotals("TRUE", start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	byModel, err := db.aiModelUsage("TRUE", start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT a.user_id, COALESCE(u.email, ''),
+			COUNT(*),
+			COUNT(*) FILTER (WHERE a.outcome IN ('error', 'invalid_response')),
+			COALESCE(SUM(a.prompt_tokens), 0),
+			COALESCE(SUM(a.completion_tokens), 0)
+		FROM ai_usage a
+		LEFT JOIN users u ON u.id = a.user_id
+		WHERE a.created_at >= $1 AND a.created_at < $2
+		GROUP BY a.user_id, u.email
+		ORDER BY SUM(a.prompt_tokens + a.completion_tokens) DESC, COUNT(*) DESC
+	`
+
+	rows, err := db.Query(query, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.AIUserUsage{}
+	for rows.Next() {
+		var user models.AIUserUsage
+		if err := rows.Scan(
+			&user.UserID, &user.Email,
+			&user.Requests, &user.Errors, &user.PromptTokens, &user.CompletionTokens,
+		); err != nil {
+			return nil, err
+		}
+		user.TotalTokens = user.PromptTokens + user.CompletionTokens
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &models.AIUsageReport{
+		Month:       start.Format("2006-01"),
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Totals:      totals,
+		ByModel:     byModel,
+		Users:       users,
+	}, nil
+}
+
+// aiUsageTotals sums the requests between start and end that match filter,
+// a condition whose parameters start at $3
+func (db *DB) aiUsageTotals(filter string, start, end time.Time, args ...interface{}) (models.AIUsageTotals, error) {
+	query := `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE outcome IN ('error', 'invalid_response')),
+			COALESCE(SUM(prompt_tokens), 0),
+			COALESCE(SUM(completion_tokens), 0)
+		FROM ai_usage
+		WHERE created_at >= $1 AND created_at < $2 AND ` + filter
+
+	var totals models.AIUsageTotals
+	err := db.QueryRow(query, append([]interface{}{start, end}, args...)...).Scan(
+		&totals.Requests, &totals.Errors, &totals.PromptTokens, &totals.CompletionTokens,
+	)
+	if err != nil {
+		return models.AIUsageTotals{}, err
+	}
+
+	totals.TotalTokens = totals.PromptTokens + totals.CompletionTokens
+	return totals, nil
+}
+
+// aiModelUsage sums the requests between start and end that match filter
+// for each provider and model, most used first
+func (db *DB) aiModelUsage(filter string, start, end time.Time, args ...interface{}) ([]models.AIModelUsage, error) {
+	query := `
+		SELECT provider, model,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE outcome IN ('error', 'invalid_response')),
+			COALESCE(SUM(prompt_tokens), 0),
+			COALESCE(SUM(completion_tokens), 0),
+			COALESCE(AVG(latency_ms), 0)::BIGINT
+		FROM ai_usage
+		WHERE created_at >= $1 AND created_at < $2 AND ` + filter + `
+		GROUP BY provider, model
+		ORDER BY COUNT(*) DESC, provider, model
+	`
+
+	rows, err := db.Query(query, append([]interface{}{start, end}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := []models.AIModelUsage{}
+	for rows.Next() {
+		var model models.AIModelUsage
+		if err := rows.Scan(
+			&model.Provider, &model.Model,
+			&model.Requests, &model.Errors, &model.PromptTokens, &model.CompletionTokens,
+			&model.AvgLatencyMs,
+		); err != nil {
+			return nil, err
+		}
+		model.TotalTokens = model.PromptTokens + model.CompletionTokens
+		usage = append(usage, model)
+	}
+	return usage, rows.Err()
+}
@@ -4,12 +4,24 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"log"
 	"saas-server/models"
+	"saas-server/pkg/boardpatch"
+	"saas-server/pkg/boardvalidate"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrBoardNotFound is returned when a board does not exist or the user cannot access it
+var ErrBoardNotFound = errors.New("board not found or access denied")
+
+// ErrBoardPermissionDenied is returned when the user can see a board but their role does not allow the operation
+var ErrBoardPermissionDenied = errors.New("insufficient permissions for this board")
+
+// ErrBoardVersionConflict is returned when an update was based on an outdated version of a board
+var ErrBoardVersionConflict = errors.New("board has been modified since it was last fetched")
+
 // BoardDB handles database operations for boards
 type BoardDB struct {
 	db *sql.DB
@@ -25,17 +37,24 @@ func (b *BoardDB) CreateBoard(userID string, req models.BoardCreateRequest) (*mo
 	// Generate a new UUID if not provided
 	id := uuid.New().String()
 
-	// Initialize empty board data
+	// Initialize empty board data unless initial content was given
 	boardData := models.BoardData{
 		Nodes: []json.RawMessage{},
 		Edges: []json.RawMessage{},
 	}
+	if req.Data != nil {
+		validData, err := validateBoardData("board "+id, *req.Data)
+		if err != nil {
+			return nil, err
+		}
+		boardData = validData
+	}
 
 	// Create the board in the database
 	query := `
-		INSERT INTO boards (id, user_id, name, description, data, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $6)
-		RETURNING id, user_id, name, description, data, created_at, updated_at
+		INSERT INTO boards (id, user_id, name, description, data, metrics, forked_from, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		RETURNING id, user_id, name, description, data, version, forked_from, created_at, updated_at
 	`
 
 	now := time.Now()
@@ -46,17 +65,36 @@ func (b *BoardDB) CreateBoard(userID string, req models.BoardCreateRequest) (*mo
 		return nil, err
 	}
 
+	// The quota check and the insert share a transaction so concurrent
+	// creates cannot both fit under the board limit
+	tx, err := b.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := b.checkBoardQuota(tx, userID, id, true, len(boardData.Nodes), dataJSON); err != nil {
+		return nil, err
+	}
+
+	metricsJSON, err := boardMetricsJSON(boardData)
+	if err != nil {
+		return nil, err
+	}
+
 	var board models.Board
 
 	// Execute the query
 	var rawData []byte
-	err = b.db.QueryRow(
+	err = tx.QueryRow(
 		query,
 		id,
 		userID,
 		req.Name,
 		req.Description,
 		dataJSON,
+		metricsJSON,
+		req.ForkedFrom,
 		now,
 	).Scan(
 		&board.ID,
@@ -64,6 +102,8 @@ func (b *BoardDB) CreateBoard(userID string, req models.BoardCreateRequest) (*mo
 		&board.Name,
 		&board.Description,
 		&rawData,
+		&board.Version,
+		&board.ForkedFrom,
 		&board.CreatedAt,
 		&board.UpdatedAt,
 	)
@@ -72,38 +112,76 @@ func (b *BoardDB) CreateBoard(userID string, req models.BoardCreateRequest) (*mo
 		return nil, err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	// Parse the JSON data back into the BoardData struct
 	if err := json.Unmarshal(rawData, &board.Data); err != nil {
 		return nil, err
 	}
 
+	board.Role = models.BoardRoleOwner
+
 	return &board, nil
 }
 
-// GetBoard retrieves a board by ID and checks if the requesting user owns it
+// boardAccessQuery selects a board ($1) together with the role of the
+// requesting user ($2), matching only if the user owns it or is a member
+const boardAccessQuery = `
+	SELECT b.id, b.user_id, b.name, b.description, b.data, b.version, b.forked_from, b.created_at, b.updated_at,
+		CASE WHEN b.user_id = $2 THEN 'owner' ELSE m.role END AS role
+	FROM boards b
+	LEFT JOIN board_members m ON m.board_id = b.id AND m.user_id = $2
+	WHERE b.id = $1 AND (b.user_id = $2 OR m.user_id IS NOT NULL) AND b.deleted_at IS NULL
+`
+
+// GetBoard retrieves a board by ID if the requesting user owns it or it has been shared with them
 func (b *BoardDB) GetBoard(boardID, userID string) (*models.Board, error) {
-	query := `
-		SELECT id, user_id, name, description, data, created_at, updated_at
-		FROM boards
-		WHERE id = $1 AND user_id = $2
-	`
+	return scanBoard(b.db.QueryRow(boardAccessQuery, boardID, userID))
+}
+
+// lockBoard retrieves a board the user may edit inside a transaction and
+// locks its row until the transaction ends, so concurrent updates are serialized
+func (b *BoardDB) lockBoard(tx *sql.Tx, boardID, userID string) (*models.Board, error) {
+	board, err := scanBoard(tx.QueryRow(boardAccessQuery+" FOR UPDATE OF b", boardID, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	if !board.CanEdit() {
+		return nil, ErrBoardPermissionDenied
+	}
+
+	return board, nil
+}
 
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanBoard scans a single board row selected with the standard column list
+func scanBoard(row rowScanner) (*models.Board, error) {
 	var board models.Board
 	var rawData []byte
 
-	err := b.db.QueryRow(query, boardID, userID).Scan(
+	err := row.Scan(
 		&board.ID,
 		&board.UserID,
 		&board.Name,
 		&board.Description,
 		&rawData,
+		&board.Version,
+		&board.ForkedFrom,
 		&board.CreatedAt,
 		&board.UpdatedAt,
+		&board.Role,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("board not found or access denied")
+			return nil, ErrBoardNotFound
 		}
 		return nil, err
 	}
@@ -116,20 +194,31 @@ func (b *BoardDB) GetBoard(boardID, userID string) (*models.Board, error) {
 	return &board, nil
 }
 
-// UpdateBoard updates an existing board's metadata and content
-func (b *BoardDB) UpdateBoard(boardID, userID string, req models.BoardUpdateRequest) (*models.Board, error) {
-	// First check if the board exists and belongs to the user
-	existingBoard, err := b.GetBoard(boardID, userID)
+// UpdateBoard updates an existing board's metadata and content. It requires
+// the owner or editor role. If expectedVersion is non-zero and does not match
+// the stored version, ErrBoardVersionConflict is returned and nothing is written.
+func (b *BoardDB) UpdateBoard(boardID, userID string, req models.BoardUpdateRequest, expectedVersion int) (*models.Board, error) {
+	// Start a transaction
+	tx, err := b.db.Begin()
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	// Start a transaction
-	tx, err := b.db.Begin()
+	// Check that the user can edit the board, locking it until commit
+	existingBoard, err := b.lockBoard(tx, boardID, userID)
 	if err != nil {
 		return nil, err
 	}
-	defer tx.Rollback()
+
+	if expectedVersion != 0 && existingBoard.Version != expectedVersion {
+		return nil, ErrBoardVersionConflict
+	}
+
+	// Keep the previous content so the update can be undone
+	if err := b.recordRevision(tx, existingBoard, userID); err != nil {
+		return nil, err
+	}
 
 	// Update metadata if provided
 	if req.Name != "" || req.Description != "" {
@@ -137,9 +226,9 @@ func (b *BoardDB) UpdateBoard(boardID, userID string, req models.BoardUpdateRequ
 			UPDATE boards 
 			SET name = COALESCE($1, name),
 				description = COALESCE($2, description)
-			WHERE id = $3 AND user_id = $4
+			WHERE id = $3
 		`
-		_, err = tx.Exec(metaQuery, req.Name, req.Description, boardID, userID)
+		_, err = tx.Exec(metaQuery, req.Name, req.Description, boardID)
 		if err != nil {
 			return nil, err
 		}
@@ -178,24 +267,45 @@ func (b *BoardDB) UpdateBoard(boardID, userID string, req models.BoardUpdateRequ
 			updatedData.Edges = edges
 		}
 
+		// Reject malformed elements and drop edges to nodes that no longer exist
+		updatedData, err = validateBoardData("board "+boardID, updatedData)
+		if err != nil {
+			return nil, err
+		}
+
 		// Convert to JSON for storage
 		dataJSON, err := json.Marshal(updatedData)
 		if err != nil {
 			return nil, err
 		}
 
-		// Update the data in the database
+		// Quotas are those of the board's owner, whoever is editing it
+		if err := b.checkBoardQuota(tx, existingBoard.UserID, boardID, false, len(updatedData.Nodes), dataJSON); err != nil {
+			return nil, err
+		}
+
+		metricsJSON, err := boardMetricsJSON(updatedData)
+		if err != nil {
+			return nil, err
+		}
+
+		// Update the data and its cached metrics in the database
 		dataQuery := `
 			UPDATE boards 
-			SET data = $1
-			WHERE id = $2 AND user_id = $3
+			SET data = $1,
+				metrics = $2
+			WHERE id = $3
 		`
-		_, err = tx.Exec(dataQuery, dataJSON, boardID, userID)
+		_, err = tx.Exec(dataQuery, dataJSON, metricsJSON, boardID)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	if err := bumpBoardVersion(tx, boardID); err != nil {
+		return nil, err
+	}
+
 	// Commit the transaction
 	if err := tx.Commit(); err != nil {
 		return nil, err
@@ -205,73 +315,133 @@ func (b *BoardDB) UpdateBoard(boardID, userID string, req models.BoardUpdateRequ
 	return b.GetBoard(boardID, userID)
 }
 
-// DeleteBoard deletes a board by ID if it belongs to the requesting user
-func (b *BoardDB) DeleteBoard(boardID, userID string) error {
-	query := `
-		DELETE FROM boards
-		WHERE id = $1 AND user_id = $2
-	`
+// PatchBoard applies incremental node and edge changes to a board inside a
+// single transaction. The version check behaves as in UpdateBoard.
+func (b *BoardDB) PatchBoard(boardID, userID string, patch models.BoardPatchRequest, expectedVersion int) (*models.Board, error) {
+	return b.patchBoard(boardID, userID, expectedVersion, func(models.BoardData) (models.BoardPatchRequest, error) {
+		return patch, nil
+	})
+}
 
-	result, err := b.db.Exec(query, boardID, userID)
+// patchBoard is PatchBoard with the patch built from the board's current
+// data while the board is locked, so it can depend on what is stored
+func (b *BoardDB) patchBoard(boardID, userID string, expectedVersion int, buildPatch func(data models.BoardData) (models.BoardPatchRequest, error)) (*models.Board, error) {
+	tx, err := b.db.Begin()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	existingBoard, err := b.lockBoard(tx, boardID, userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if rowsAffected == 0 {
-		return errors.New("board not found or access denied")
+	if expectedVersion != 0 && existingBoard.Version != expectedVersion {
+		return nil, ErrBoardVersionConflict
 	}
 
-	return nil
+	patch, err := buildPatch(existingBoard.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedData, err := boardpatch.Apply(existingBoard.Data, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedData, err = validateBoardData("board "+boardID, updatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.recordRevision(tx, existingBoard, userID); err != nil {
+		return nil, err
+	}
+
+	dataJSON, err := json.Marshal(updatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.checkBoardQuota(tx, existingBoard.UserID, boardID, false, len(updatedData.Nodes), dataJSON); err != nil {
+		return nil, err
+	}
+
+	metricsJSON, err := boardMetricsJSON(updatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`UPDATE boards SET data = $1, metrics = $2 WHERE id = $3`, dataJSON, metricsJSON, boardID); err != nil {
+		return nil, err
+	}
+
+	if err := bumpBoardVersion(tx, boardID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return b.GetBoard(boardID, userID)
+}
+
+// validateBoardData checks board data before it is stored, returning a
+// *boardvalidate.Error for malformed nodes and edges. Edges whose source or
+// target node is missing are dropped; source names the board or template in the log.
+func validateBoardData(source string, data models.BoardData) (models.BoardData, error) {
+	result, err := boardvalidate.Validate(data)
+	if err != nil {
+		return data, err
+	}
+
+	if len(result.DroppedEdges) > 0 {
+		log.Printf("[DB] Dropped %d dangling edges from %s: %v", len(result.DroppedEdges), source, result.DroppedEdges)
+	}
+
+	return result.Data, nil
 }
 
-// ListBoards retrieves all boards belonging to a user
-func (b *BoardDB) ListBoards(userID string) ([]models.BoardListItem, error) {
+// bumpBoardVersion increments a board's version after its content changed
+func bumpBoardVersion(tx *sql.Tx, boardID string) error {
+	_, err := tx.Exec(`UPDATE boards SET version = version + 1 WHERE id = $1`, boardID)
+	return err
+}
+
+// DeleteBoard moves a board to the trash. Only the owner may delete a board.
+// Trashed boards are hidden everywhere until restored or purged.
+func (b *BoardDB) DeleteBoard(boardID, userID string) error {
+	board, err := b.GetBoard(boardID, userID)
+	if err != nil {
+		return err
+	}
+
+	if board.Role != models.BoardRoleOwner {
+		return ErrBoardPermissionDenied
+	}
+
 	query := `
-		SELECT 
-			id, 
-			name, 
-			description, 
-			jsonb_array_length(data->'nodes') as node_count,
-			jsonb_array_length(data->'edges') as edge_count, 
-			created_at, 
-			updated_at
-		FROM boards
-		WHERE user_id = $1
-		ORDER BY updated_at DESC
+		UPDATE boards
+		SET deleted_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 	`
 
-	rows, err := b.db.Query(query, userID)
+	result, err := b.db.Exec(query, boardID, userID)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
-
-	var boardList []models.BoardListItem
-
-	for rows.Next() {
-		var board models.BoardListItem
-		if err := rows.Scan(
-			&board.ID,
-			&board.Name,
-			&board.Description,
-			&board.NodeCount,
-			&board.EdgeCount,
-			&board.CreatedAt,
-			&board.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		boardList = append(boardList, board)
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+	if rowsAffected == 0 {
+		return ErrBoardNotFound
 	}
 
-	return boardList, nil
+	return nil
 }
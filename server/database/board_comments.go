@@ -0,0 +1,426 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"saas-server/models"
+	"saas-server/pkg/boardpatch"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrCommentNotFound is returned when a board has no comment with the requested ID
+var ErrCommentNotFound = errors.New("comment not found")
+
+// ErrCommentNotThread is returned when resolving a reply instead of the thread it belongs to
+var ErrCommentNotThread = errors.New("only top-level comments can be resolved")
+
+// ErrInvalidMention is returned when a comment mentions a user without access to the board
+var ErrInvalidMention = errors.New("mentioned user does not have access to the board")
+
+// boardCommentColumns is the column list read by scanComment
+const boardCommentColumns = `
+	c.id, c.board_id, c.node_id, c.parent_id, COALESCE(c.user_id::text, ''),
+	COALESCE(u.name, ''), c.body, c.mentions, c.resolved_at, c.resolved_by,
+	c.created_at, c.updated_at
+`
+
+// ListComments retrieves the comment threads of a board, oldest first, with
+// their replies. When nodeID is set only the threads on that node are
+// returned. Any user with access to the board may read its comments.
+func (b *BoardDB) ListComments(boardID, userID, nodeID string) ([]models.BoardComment, error) {
+	if _, err := b.GetBoard(boardID, userID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + boardCommentColumns + `
+		FROM board_comments c
+		LEFT JOIN users u ON u.id = c.user_id
+		WHERE c.board_id = $1 AND ($2 = '' OR c.node_id = $2)
+		ORDER BY c.created_at ASC, c.id ASC
+	`
+
+	rows, err := b.db.Query(query, boardID, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []*models.BoardComment
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := b.fillMentions(comments...); err != nil {
+		return nil, err
+	}
+
+	// Replies always belong to a top-level comment on the same node
+	replies := make(map[string][]models.BoardComment)
+	for _, comment := range comments {
+		if comment.ParentID != nil {
+			replies[*comment.ParentID] = append(replies[*comment.ParentID], *comment)
+		}
+	}
+
+	threads := []models.BoardComment{}
+	for _, comment := range comments {
+		if comment.ParentID == nil {
+			comment.Replies = replies[comment.ID]
+			threads = append(threads, *comment)
+		}
+	}
+
+	return threads, nil
+}
+
+// CreateComment adds a comment to a node of the board, or a reply to a
+// thread when req.ParentID is set. Replying to a reply adds to the same
+// thread. Any user with access to the board may comment. It returns the new
+// comment and the users it mentions so they can be notified.
+func (b *BoardDB) CreateComment(boardID, userID string, req models.BoardCommentCreateRequest) (*models.BoardComment, []models.BoardCommentMention, error) {
+	board, err := b.GetBoard(boardID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nodeID := req.NodeID
+	var parentID *string
+	if req.ParentID != "" {
+		parent, err := b.getComment(boardID, req.ParentID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		nodeID = parent.NodeID
+		parentID = &parent.ID
+		if parent.ParentID != nil {
+			parentID = parent.ParentID
+		}
+	} else if _, ok := boardpatch.Find(board.Data.Nodes, nodeID); !ok {
+		return nil, nil, ErrNodeNotFound
+	}
+
+	mentions, err := b.commentMentions(board, userID, req.Mentions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := `
+		INSERT INTO board_comments (board_id, node_id, parent_id, user_id, body, mentions, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		RETURNING id
+	`
+
+	var commentID string
+	err = b.db.QueryRow(query, boardID, nodeID, parentID, userID, req.Body, pq.Array(mentionIDs(mentions))).Scan(&commentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comment, err := b.getComment(boardID, commentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return comment, mentions, nil
+}
+
+// UpdateComment replaces the body and mentions of a comment. Only its author
+// may edit a comment. It returns the updated comment and the users who were
+// not mentioned before so only they are notified.
+func (b *BoardDB) UpdateComment(boardID, commentID, userID string, req models.BoardCommentUpdateRequest) (*models.BoardComment, []models.BoardCommentMention, error) {
+	board, err := b.GetBoard(boardID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existing, err := b.getComment(boardID, commentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if existing.UserID != userID {
+		return nil, nil, ErrBoardPermissionDenied
+	}
+
+	mentions, err := b.commentMentions(board, userID, req.Mentions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := `
+		UPDATE board_comments
+		SET body = $1,
+			mentions = $2,
+			updated_at = NOW()
+		WHERE id = $3 AND board_id = $4
+	`
+	if _, err := b.db.Exec(query, req.Body, pq.Array(mentionIDs(mentions)), commentID, boardID); err != nil {
+		return nil, nil, err
+	}
+
+	comment, err := b.getComment(boardID, commentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	alreadyMentioned := make(map[string]bool, len(existing.Mentions))
+	for _, mention := range existing.Mentions {
+		alreadyMentioned[mention.UserID] = true
+	}
+	var added []models.BoardCommentMention
+	for _, mention := range mentions {
+		if !alreadyMentioned[mention.UserID] {
+			added = append(added, mention)
+		}
+	}
+
+	return comment, added, nil
+}
+
+// ResolveComment marks a thread as resolved or reopens it. The thread's
+// author and anyone who can edit the board may resolve it.
+func (b *BoardDB) ResolveComment(boardID, commentID, userID string, resolved bool) (*models.BoardComment, error) {
+	board, err := b.GetBoard(boardID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := b.getComment(boardID, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.ParentID != nil {
+		return nil, ErrCommentNotThread
+	}
+
+	if !board.CanEdit() && comment.UserID != userID {
+		return nil, ErrBoardPermissionDenied
+	}
+
+	// Resolving an already resolved thread keeps who resolved it first
+	query := `
+		UPDATE board_comments
+		SET resolved_at = CASE WHEN $1 THEN COALESCE(resolved_at, NOW()) END,
+			resolved_by = CASE WHEN $1 THEN COALESCE(resolved_by, $2::uuid) END
+		WHERE id = $3 AND board_id = $4
+	`
+	if _, err := b.db.Exec(query, resolved, userID, commentID, boardID); err != nil {
+		return nil, err
+	}
+
+	return b.getComment(boardID, commentID)
+}
+
+// DeleteComment removes a comment and, for a thread, all of its replies. The
+// comment's author and the board owner may delete it.
+func (b *BoardDB) DeleteComment(boardID, commentID, userID string) error {
+	board, err := b.GetBoard(boardID, userID)
+	if err != nil {
+		return err
+	}
+
+	comment, err := b.getComment(boardID, commentID)
+	if err != nil {
+		return err
+	}
+
+	if board.Role != models.BoardRoleOwner && comment.UserID != userID {
+		return ErrBoardPermissionDenied
+	}
+
+	result, err := b.db.Exec(`DELETE FROM board_comments WHERE id = $1 AND board_id = $2`, commentID, boardID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrCommentNotFound
+	}
+
+	return nil
+}
+
+// getComment loads a single comment of a board with its mentions
+func (b *BoardDB) getComment(boardID, commentID string) (*models.BoardComment, error) {
+	query := `
+		SELECT ` + boardCommentColumns + `
+		FROM board_comments c
+		LEFT JOIN users u ON u.id = c.user_id
+		WHERE c.id = $1 AND c.board_id = $2
+	`
+
+	comment, err := scanComment(b.db.QueryRow(query, commentID, boardID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+
+	if err := b.fillMentions(comment); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// commentMentions resolves the IDs of users mentioned by author to users with
+// access to the board, that is its owner and members. Duplicates and the
+// author mentioning themselves are dropped.
+func (b *BoardDB) commentMentions(board *models.Board, authorID string, userIDs []string) ([]models.BoardCommentMention, error) {
+	seen := make(map[string]bool, len(userIDs))
+	var ids []string
+	for _, id := range userIDs {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, ErrInvalidMention
+		}
+		id = parsed.String()
+		if id == authorID || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 {
+		return []models.BoardCommentMention{}, nil
+	}
+
+	query := `
+		SELECT u.id, u.name, u.email
+		FROM users u
+		WHERE u.id = ANY($1::uuid[])
+			AND (u.id = $2 OR EXISTS (
+				SELECT 1 FROM board_members m WHERE m.board_id = $3 AND m.user_id = u.id
+			))
+	`
+
+	mentions, err := b.queryMentions(query, pq.Array(ids), board.UserID, board.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(mentions) != len(ids) {
+		return nil, ErrInvalidMention
+	}
+
+	return mentions, nil
+}
+
+// fillMentions replaces the user IDs scanned into the comments' mentions
+// with the mentioned users' details. Users that no longer exist are dropped.
+func (b *BoardDB) fillMentions(comments ...*models.BoardComment) error {
+	var ids []string
+	for _, comment := range comments {
+		ids = append(ids, mentionIDs(comment.Mentions)...)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	users, err := b.queryMentions(`SELECT id, name, email FROM users WHERE id = ANY($1::uuid[])`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]models.BoardCommentMention, len(users))
+	for _, user := range users {
+		byID[user.UserID] = user
+	}
+
+	for _, comment := range comments {
+		mentions := []models.BoardCommentMention{}
+		for _, mention := range comment.Mentions {
+			if user, ok := byID[mention.UserID]; ok {
+				mentions = append(mentions, user)
+			}
+		}
+		comment.Mentions = mentions
+	}
+
+	return nil
+}
+
+// queryMentions runs a query selecting the id, name and email of users
+func (b *BoardDB) queryMentions(query string, args ...interface{}) ([]models.BoardCommentMention, error) {
+	rows, err := b.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mentions := []models.BoardCommentMention{}
+	for rows.Next() {
+		var mention models.BoardCommentMention
+		if err := rows.Scan(&mention.UserID, &mention.Name, &mention.Email); err != nil {
+			return nil, err
+		}
+		mentions = append(mentions, mention)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return mentions, nil
+}
+
+// scanComment scans a comment selected with boardCommentColumns. Mentions
+// only hold user IDs until fillMentions is called.
+func scanComment(row rowScanner) (*models.BoardComment, error) {
+	var comment models.BoardComment
+	var mentions pq.StringArray
+
+	err := row.Scan(
+		&comment.ID,
+		&comment.BoardID,
+		&comment.NodeID,
+		&comment.ParentID,
+		&comment.UserID,
+		&comment.AuthorName,
+		&comment.Body,
+		&mentions,
+		&comment.ResolvedAt,
+		&comment.ResolvedBy,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	comment.Resolved = comment.ResolvedAt != nil
+	comment.Mentions = make([]models.BoardCommentMention, 0, len(mentions))
+	for _, id := range mentions {
+		comment.Mentions = append(comment.Mentions, models.BoardCommentMention{UserID: id})
+	}
+
+	return &comment, nil
+}
+
+// mentionIDs returns the user IDs of mentions
+func mentionIDs(mentions []models.BoardCommentMention) []string {
+	ids := make([]string, 0, len(mentions))
+	for _, mention := range mentions {
+		ids = append(ids, mention.UserID)
+	}
+	return ids
+}
@@ -0,0 +1,368 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"saas-server/models"
+	"saas-server/pkg/boardgraph"
+	"saas-server/pkg/boardpatch"
+
+	"github.com/google/uuid"
+)
+
+// ErrNodeNotFound is returned when a board has no node with the requested ID
+var ErrNodeNotFound = errors.New("node not found")
+
+// ErrEdgeNotFound is returned when a board has no edge with the requested ID
+var ErrEdgeNotFound = errors.New("edge not found")
+
+const (
+	// childColumnOffset and childRowOffset place a node created under a parent
+	// to the right of it, below the parent's existing children
+	childColumnOffset = 320
+	childRowOffset    = 110
+)
+
+// GetNode retrieves a board and one of its nodes
+func (b *BoardDB) GetNode(boardID, userID, nodeID string) (*models.Board, json.RawMessage, error) {
+	board, err := b.GetBoard(boardID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	node, ok := boardpatch.Find(board.Data.Nodes, nodeID)
+	if !ok {
+		return nil, nil, ErrNodeNotFound
+	}
+
+	return board, node, nil
+}
+
+// GetEdge retrieves a board and one of its edges
+func (b *BoardDB) GetEdge(boardID, userID, edgeID string) (*models.Board, json.RawMessage, error) {
+	board, err := b.GetBoard(boardID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	edge, ok := boardpatch.Find(board.Data.Edges, edgeID)
+	if !ok {
+		return nil, nil, ErrEdgeNotFound
+	}
+
+	return board, edge, nil
+}
+
+// AddNode appends a node to a board and returns the updated board with the
+// IDs of the new node and, when parentID is set, of the edge connecting the
+// parent to it. A missing id is generated, a missing type defaults to
+// textNode and a missing position places the node next to its parent, or
+// below the existing nodes when there is no parent.
+func (b *BoardDB) AddNode(boardID, userID string, rawNode json.RawMessage, parentID string, expectedVersion int) (*models.Board, string, string, error) {
+	var nodeID, edgeID string
+
+	board, err := b.patchBoard(boardID, userID, expectedVersion, func(data models.BoardData) (models.BoardPatchRequest, error) {
+		var patch models.BoardPatchRequest
+
+		node, err := decodeElement("node", rawNode)
+		if err != nil {
+			return patch, err
+		}
+
+		nodeID, err = elementString(node, "id")
+		if err != nil {
+			return patch, err
+		}
+		if nodeID == "" {
+			nodeID = "node-" + uuid.New().String()
+			node["id"], _ = json.Marshal(nodeID)
+		}
+		if _, ok := node["type"]; !ok {
+			node["type"], _ = json.Marshal("textNode")
+		}
+
+		graph, err := boardgraph.Parse(data)
+		if err != nil {
+			return patch, err
+		}
+
+		var parent *boardgraph.Node
+		if parentID != "" {
+			if parent = graph.Node(parentID); parent == nil {
+				return patch, fmt.Errorf("%w: parent node %q does not exist", boardpatch.ErrInvalidPatch, parentID)
+			}
+		}
+
+		if _, ok := node["position"]; !ok {
+			node["position"], _ = json.Marshal(newNodePosition(graph, parent))
+		}
+
+		encoded, err := json.Marshal(node)
+		if err != nil {
+			return patch, err
+		}
+		patch.Nodes.Add = []json.RawMessage{encoded}
+
+		if parent != nil {
+			edgeID = "edge-" + uuid.New().String()
+			edge, err := json.Marshal(map[string]string{
+				"id":           edgeID,
+				"source":       parent.ID,
+				"target":       nodeID,
+				"type":         "default",
+				"sourceHandle": "right",
+				"targetHandle": "left",
+			})
+			if err != nil {
+				return patch, err
+			}
+			patch.Edges.Add = []json.RawMessage{edge}
+		}
+
+		return patch, nil
+	})
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	return board, nodeID, edgeID, nil
+}
+
+// UpdateNode merges a JSON Merge Patch into a node. The node's id cannot be changed.
+func (b *BoardDB) UpdateNode(boardID, userID, nodeID string, rawPatch json.RawMessage, expectedVersion int) (*models.Board, error) {
+	return b.patchBoard(boardID, userID, expectedVersion, func(data models.BoardData) (models.BoardPatchRequest, error) {
+		var patch models.BoardPatchRequest
+
+		if _, ok := boardpatch.Find(data.Nodes, nodeID); !ok {
+			return patch, ErrNodeNotFound
+		}
+
+		update, err := elementUpdate("node", nodeID, rawPatch)
+		if err != nil {
+			return patch, err
+		}
+
+		patch.Nodes.Update = []json.RawMessage{update}
+		return patch, nil
+	})
+}
+
+// DeleteNode removes a node and every edge connected to it, returning the
+// updated board and the IDs of the removed edges
+func (b *BoardDB) DeleteNode(boardID, userID, nodeID string, expectedVersion int) (*models.Board, []string, error) {
+	removedEdges := []string{}
+
+	board, err := b.patchBoard(boardID, userID, expectedVersion, func(data models.BoardData) (models.BoardPatchRequest, error) {
+		var patch models.BoardPatchRequest
+
+		if _, ok := boardpatch.Find(data.Nodes, nodeID); !ok {
+			return patch, ErrNodeNotFound
+		}
+
+		for _, raw := range data.Edges {
+			var edge struct {
+				ID     string `json:"id"`
+				Source string `json:"source"`
+				Target string `json:"target"`
+			}
+			if err := json.Unmarshal(raw, &edge); err != nil {
+				return patch, err
+			}
+			if edge.Source == nodeID || edge.Target == nodeID {
+				removedEdges = append(removedEdges, edge.ID)
+			}
+		}
+
+		patch.Nodes.Remove = []string{nodeID}
+		patch.Edges.Remove = removedEdges
+		return patch, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return board, removedEdges, nil
+}
+
+// AddEdge appends an edge to a board and returns the updated board with the
+// new edge's ID, which is generated when missing. Both ends must exist.
+func (b *BoardDB) AddEdge(boardID, userID string, rawEdge json.RawMessage, expectedVersion int) (*models.Board, string, error) {
+	var edgeID string
+
+	board, err := b.patchBoard(boardID, userID, expectedVersion, func(data models.BoardData) (models.BoardPatchRequest, error) {
+		var patch models.BoardPatchRequest
+
+		edge, err := decodeElement("edge", rawEdge)
+		if err != nil {
+			return patch, err
+		}
+
+		edgeID, err = elementString(edge, "id")
+		if err != nil {
+			return patch, err
+		}
+		if edgeID == "" {
+			edgeID = "edge-" + uuid.New().String()
+			edge["id"], _ = json.Marshal(edgeID)
+		}
+
+		if err := checkEdgeEnds(data, edge); err != nil {
+			return patch, err
+		}
+
+		encoded, err := json.Marshal(edge)
+		if err != nil {
+			return patch, err
+		}
+
+		patch.Edges.Add = []json.RawMessage{encoded}
+		return patch, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return board, edgeID, nil
+}
+
+// UpdateEdge merges a JSON Merge Patch into an edge. The edge's id cannot be
+// changed, and a new source or target must exist.
+func (b *BoardDB) UpdateEdge(boardID, userID, edgeID string, rawPatch json.RawMessage, expectedVersion int) (*models.Board, error) {
+	return b.patchBoard(boardID, userID, expectedVersion, func(data models.BoardData) (models.BoardPatchRequest, error) {
+		var patch models.BoardPatchRequest
+
+		existing, ok := boardpatch.Find(data.Edges, edgeID)
+		if !ok {
+			return patch, ErrEdgeNotFound
+		}
+
+		update, err := elementUpdate("edge", edgeID, rawPatch)
+		if err != nil {
+			return patch, err
+		}
+
+		// Check the ends of the edge as it will be stored, since edges pointing
+		// at missing nodes would otherwise be dropped silently
+		merged, err := boardpatch.MergePatch(existing, update)
+		if err != nil {
+			return patch, fmt.Errorf("%w: %v", boardpatch.ErrInvalidPatch, err)
+		}
+		edge, err := decodeElement("edge", merged)
+		if err != nil {
+			return patch, err
+		}
+		if err := checkEdgeEnds(data, edge); err != nil {
+			return patch, err
+		}
+
+		patch.Edges.Update = []json.RawMessage{update}
+		return patch, nil
+	})
+}
+
+// DeleteEdge removes an edge from a board
+func (b *BoardDB) DeleteEdge(boardID, userID, edgeID string, expectedVersion int) (*models.Board, error) {
+	return b.patchBoard(boardID, userID, expectedVersion, func(data models.BoardData) (models.BoardPatchRequest, error) {
+		var patch models.BoardPatchRequest
+
+		if _, ok := boardpatch.Find(data.Edges, edgeID); !ok {
+			return patch, ErrEdgeNotFound
+		}
+
+		patch.Edges.Remove = []string{edgeID}
+		return patch, nil
+	})
+}
+
+// newNodePosition places a new node to the right of its parent below the
+// parent's existing children, or under the lowest node when it has no parent
+func newNodePosition(graph *boardgraph.Graph, parent *boardgraph.Node) map[string]float64 {
+	if parent != nil {
+		children := 0
+		for _, edge := range graph.Edges {
+			if edge.Source == parent.ID {
+				children++
+			}
+		}
+		return map[string]float64{
+			"x": parent.X + childColumnOffset,
+			"y": parent.Y + float64(children*childRowOffset),
+		}
+	}
+
+	if len(graph.Nodes) == 0 {
+		return map[string]float64{"x": 0, "y": 0}
+	}
+
+	x, y := graph.Nodes[0].X, graph.Nodes[0].Y
+	for _, node := range graph.Nodes {
+		if node.X < x {
+			x = node.X
+		}
+		if node.Y > y {
+			y = node.Y
+		}
+	}
+	return map[string]float64{"x": x, "y": y + childRowOffset}
+}
+
+// elementUpdate turns a merge patch for the element with the given ID into a
+// boardpatch update, rejecting attempts to change the ID
+func elementUpdate(kind, id string, rawPatch json.RawMessage) (json.RawMessage, error) {
+	update, err := decodeElement(kind, rawPatch)
+	if err != nil {
+		return nil, err
+	}
+
+	patchID, err := elementString(update, "id")
+	if err != nil {
+		return nil, err
+	}
+	if patchID != "" && patchID != id {
+		return nil, fmt.Errorf("%w: %s id cannot be changed", boardpatch.ErrInvalidPatch, kind)
+	}
+
+	update["id"], _ = json.Marshal(id)
+	return json.Marshal(update)
+}
+
+// checkEdgeEnds verifies that an edge's source and target nodes exist
+func checkEdgeEnds(data models.BoardData, edge map[string]json.RawMessage) error {
+	for _, end := range []string{"source", "target"} {
+		nodeID, err := elementString(edge, end)
+		if err != nil {
+			return err
+		}
+		if nodeID == "" {
+			return fmt.Errorf("%w: edge %s is required", boardpatch.ErrInvalidPatch, end)
+		}
+		if _, ok := boardpatch.Find(data.Nodes, nodeID); !ok {
+			return fmt.Errorf("%w: edge %s node %q does not exist", boardpatch.ErrInvalidPatch, end, nodeID)
+		}
+	}
+	return nil
+}
+
+// decodeElement decodes a node, edge or merge patch that must be a JSON object
+func decodeElement(kind string, raw json.RawMessage) (map[string]json.RawMessage, error) {
+	var element map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &element); err != nil || element == nil {
+		return nil, fmt.Errorf("%w: %s must be an object", boardpatch.ErrInvalidPatch, kind)
+	}
+	return element, nil
+}
+
+// elementString reads an optional string field of an element
+func elementString(element map[string]json.RawMessage, field string) (string, error) {
+	raw, ok := element[field]
+	if !ok || string(raw) == "null" {
+		return "", nil
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("%w: %s must be a string", boardpatch.ErrInvalidPatch, field)
+	}
+	return value, nil
+}
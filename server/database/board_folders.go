@@ -0,0 +1,360 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"saas-server/models"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ErrFolderNotFound is returned when a folder does not exist or belongs to another user
+var ErrFolderNotFound = errors.New("folder not found")
+
+// ErrFolderNameTaken is returned when a sibling folder already has the same name
+var ErrFolderNameTaken = errors.New("a folder with that name already exists here")
+
+// ErrFolderCycle is returned when a folder would be moved inside itself
+var ErrFolderCycle = errors.New("a folder cannot be moved inside itself")
+
+// ErrTooManyTags is returned when a board would have more than MaxBoardTags tags
+var ErrTooManyTags = errors.New("too many tags")
+
+// ErrInvalidTag is returned for empty or overlong tags
+var ErrInvalidTag = errors.New("tags must be between 1 and 50 characters")
+
+const (
+	// MaxBoardTags caps the number of tags on a single board
+	MaxBoardTags = 20
+	// maxTagLength matches the board_tags.tag column
+	maxTagLength = 50
+	// uniqueViolation is the Postgres error code for unique constraint violations
+	uniqueViolation = "23505"
+)
+
+// ListFolders retrieves all of a user's folders ordered by path
+func (b *BoardDB) ListFolders(userID string) ([]models.BoardFolder, error) {
+	folders, err := b.loadFolders(b.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]models.BoardFolder, 0, len(folders))
+	for _, folder := range folders {
+		list = append(list, *folder)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return strings.ToLower(list[i].Path) < strings.ToLower(list[j].Path)
+	})
+
+	return list, nil
+}
+
+// CreateFolder creates a folder for a user, optionally inside another of their folders
+func (b *BoardDB) CreateFolder(userID, name string, parentID *string) (*models.BoardFolder, error) {
+	if parentID != nil {
+		if err := b.requireFolder(userID, *parentID); err != nil {
+			return nil, err
+		}
+	}
+
+	var folderID string
+	err := b.db.QueryRow(
+		`INSERT INTO board_folders (user_id, parent_id, name, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		RETURNING id`,
+		userID, parentID, name,
+	).Scan(&folderID)
+	if err != nil {
+		return nil, folderWriteError(err)
+	}
+
+	return b.getFolder(userID, folderID)
+}
+
+// UpdateFolder renames a folder and moves it under parentID. The user's
+// folders are locked while the move is checked and stored, so concurrent
+// moves cannot together create a cycle.
+func (b *BoardDB) UpdateFolder(userID, folderID, name string, parentID *string) (*models.BoardFolder, error) {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, userID); err != nil {
+		return nil, err
+	}
+
+	folders, err := b.loadFolders(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if folders[folderID] == nil {
+		return nil, ErrFolderNotFound
+	}
+
+	if parentID != nil {
+		if folders[*parentID] == nil {
+			return nil, ErrFolderNotFound
+		}
+		// Walk up from the new parent; reaching the folder means it would
+		// contain itself. Revisiting a folder means the stored tree already
+		// has a cycle, which the move must not join either.
+		visited := make(map[string]bool)
+		for current := folders[*parentID]; current != nil; {
+			if current.ID == folderID || visited[current.ID] {
+				return nil, ErrFolderCycle
+			}
+			visited[current.ID] = true
+			if current.ParentID == nil {
+				break
+			}
+			current = folders[*current.ParentID]
+		}
+	}
+
+	_, err = tx.Exec(
+		`UPDATE board_folders SET name = $1, parent_id = $2, updated_at = NOW() WHERE id = $3 AND user_id = $4`,
+		name, parentID, folderID, userID,
+	)
+	if err != nil {
+		return nil, folderWriteError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return b.getFolder(userID, folderID)
+}
+
+// DeleteFolder deletes a folder and its subfolders. Boards filed in them are
+// not deleted; they move to the top level.
+func (b *BoardDB) DeleteFolder(userID, folderID string) error {
+	result, err := b.db.Exec(`DELETE FROM board_folders WHERE id = $1 AND user_id = $2`, folderID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrFolderNotFound
+	}
+
+	return nil
+}
+
+// MoveBoard files a board in one of the owner's folders, or at the top level
+// when folderID is nil. Only the owner may move a board. Moving does not
+// change the board's updated_at, which tracks edits to its content.
+func (b *BoardDB) MoveBoard(boardID, userID string, folderID *string) error {
+	if err := b.requireOwner(boardID, userID); err != nil {
+		return err
+	}
+
+	if folderID != nil {
+		if err := b.requireFolder(userID, *folderID); err != nil {
+			return err
+		}
+	}
+
+	_, err := b.db.Exec(`UPDATE boards SET folder_id = $1 WHERE id = $2`, folderID, boardID)
+	return err
+}
+
+// NormalizeTags trims and lower-cases tags and removes duplicates, keeping
+// the order they were given in
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+		if tag == "" || len([]rune(tag)) > maxTagLength {
+			return nil, ErrInvalidTag
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > MaxBoardTags {
+		return nil, ErrTooManyTags
+	}
+
+	return normalized, nil
+}
+
+// SetBoardTags replaces the tags of a board. Owners and editors may tag a board.
+// Tags must already be normalized with NormalizeTags.
+func (b *BoardDB) SetBoardTags(boardID, userID string, tags []string) ([]string, error) {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := b.lockBoard(tx, boardID, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM board_tags WHERE board_id = $1`, boardID); err != nil {
+		return nil, err
+	}
+
+	if len(tags) > 0 {
+		_, err := tx.Exec(
+			`INSERT INTO board_tags (board_id, tag, created_at)
+			SELECT $1, tag, NOW() FROM unnest($2::text[]) AS tag`,
+			boardID, pq.Array(tags),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	sorted := append([]string{}, tags...)
+	sort.Strings(sorted)
+	return sorted, nil
+}
+
+// ListTags retrieves every tag used on the boards a user can access, with the
+// number of boards carrying each one
+func (b *BoardDB) ListTags(userID string) ([]models.BoardTagCount, error) {
+	query := `
+		SELECT t.tag, COUNT(*)
+		FROM board_tags t
+		JOIN boards b ON b.id = t.board_id
+		LEFT JOIN board_members m ON m.board_id = b.id AND m.user_id = $1
+		WHERE (b.user_id = $1 OR m.user_id IS NOT NULL) AND b.deleted_at IS NULL
+		GROUP BY t.tag
+		ORDER BY t.tag
+	`
+
+	rows, err := b.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.BoardTagCount{}
+	for rows.Next() {
+		var tag models.BoardTagCount
+		if err := rows.Scan(&tag.Tag, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// loadFolders retrieves a user's folders keyed by ID, with paths filled in
+func (b *BoardDB) loadFolders(q querier, userID string) (map[string]*models.BoardFolder, error) {
+	rows, err := q.Query(
+		`SELECT id, parent_id, name, created_at, updated_at FROM board_folders WHERE user_id = $1`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	folders := make(map[string]*models.BoardFolder)
+	for rows.Next() {
+		var folder models.BoardFolder
+		var parentID sql.NullString
+		if err := rows.Scan(&folder.ID, &parentID, &folder.Name, &folder.CreatedAt, &folder.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if parentID.Valid {
+			folder.ParentID = &parentID.String
+		}
+		folders[folder.ID] = &folder
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, folder := range folders {
+		folder.Path = folderPath(folders, folder.ID)
+	}
+
+	return folders, nil
+}
+
+// folderPath joins the names of a folder and its ancestors with "/"
+func folderPath(folders map[string]*models.BoardFolder, folderID string) string {
+	var names []string
+	visited := make(map[string]bool)
+
+	for current := folders[folderID]; current != nil && !visited[current.ID]; {
+		visited[current.ID] = true
+		names = append([]string{current.Name}, names...)
+		if current.ParentID == nil {
+			break
+		}
+		current = folders[*current.ParentID]
+	}
+
+	return strings.Join(names, "/")
+}
+
+// getFolder loads a single folder with its path
+func (b *BoardDB) getFolder(userID, folderID string) (*models.BoardFolder, error) {
+	folders, err := b.loadFolders(b.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	folder, ok := folders[folderID]
+	if !ok {
+		return nil, ErrFolderNotFound
+	}
+
+	return folder, nil
+}
+
+// requireFolder checks that a folder exists and belongs to the user
+func (b *BoardDB) requireFolder(userID, folderID string) error {
+	var exists bool
+	err := b.db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM board_folders WHERE id = $1 AND user_id = $2)`,
+		folderID, userID,
+	).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrFolderNotFound
+	}
+
+	return nil
+}
+
+// folderWriteError maps unique violations on folder names to ErrFolderNameTaken
+func folderWriteError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return ErrFolderNameTaken
+	}
+	return err
+}
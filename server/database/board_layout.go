@@ -0,0 +1,60 @@
+package database
+
+import (
+	"encoding/json"
+	"saas-server/models"
+	"saas-server/pkg/boardgraph"
+	"saas-server/pkg/boardlayout"
+)
+
+// LayoutBoard moves every node of a board to the position computed by
+// algorithm and returns the updated board with the new positions. The layout
+// is computed from the data stored when the board is locked, so concurrent
+// edits are not lost; the version check behaves as in UpdateBoard.
+func (b *BoardDB) LayoutBoard(boardID, userID string, algorithm boardlayout.Algorithm, expectedVersion int) (*models.Board, map[string]boardlayout.Position, error) {
+	var positions map[string]boardlayout.Position
+
+	board, err := b.patchBoard(boardID, userID, expectedVersion, func(data models.BoardData) (models.BoardPatchRequest, error) {
+		var patch models.BoardPatchRequest
+
+		graph, err := boardgraph.Parse(data)
+		if err != nil {
+			return patch, err
+		}
+		positions = algorithm.Layout(graph)
+
+		// Each update only holds the node's new position, so merging it
+		// leaves every other field of the node untouched
+		for _, raw := range data.Nodes {
+			var node map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &node); err != nil {
+				return patch, err
+			}
+			var id string
+			if err := json.Unmarshal(node["id"], &id); err != nil {
+				continue
+			}
+			position, ok := positions[id]
+			if !ok {
+				continue
+			}
+
+			update := map[string]interface{}{"id": id, "position": position}
+			if _, ok := node["positionAbsolute"]; ok {
+				update["positionAbsolute"] = position
+			}
+
+			encoded, err := json.Marshal(update)
+			if err != nil {
+				return patch, err
+			}
+			patch.Nodes.Update = append(patch.Nodes.Update, encoded)
+		}
+		return patch, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return board, positions, nil
+}
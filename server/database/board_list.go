@@ -0,0 +1,280 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"saas-server/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Sort orders for ListBoards
+const (
+	BoardSortName    = "name"
+	BoardSortCreated = "created"
+	BoardSortUpdated = "updated"
+)
+
+// ErrInvalidCursor is returned when a list cursor is malformed or was issued
+// for a different sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// BoardListOptions filters, sorts and pages ListBoards
+type BoardListOptions struct {
+	// FolderID limits the list to a folder of the user; TopLevel limits it to
+	// the user's boards that are not in any folder
+	FolderID          string
+	TopLevel          bool
+	IncludeSubfolders bool
+	// Tags limits the list to boards carrying every one of the tags
+	Tags []string
+	// From and To limit DateField ("created" or "updated") to a range
+	DateField string
+	From      *time.Time
+	To        *time.Time
+	// Sort is one of the BoardSort constants; Desc reverses it
+	Sort string
+	Desc bool
+	// Limit is the page size; zero lists every matching board on one page
+	Limit  int
+	Cursor string
+}
+
+// boardCursor is the position after the last board of a page
+type boardCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// ListBoards retrieves the boards a user owns or that have been shared with
+// them, one page at a time. It returns the cursor of the next page, which is
+// empty on the last page.
+func (b *BoardDB) ListBoards(userID string, opts BoardListOptions) ([]models.BoardListItem, string, error) {
+	args := []interface{}{userID}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{
+		"(b.user_id = $1 OR m.user_id IS NOT NULL)",
+		"b.deleted_at IS NULL",
+	}
+
+	switch {
+	case opts.TopLevel:
+		conditions = append(conditions, "b.user_id = $1 AND b.folder_id IS NULL")
+	case opts.FolderID != "" && opts.IncludeSubfolders:
+		conditions = append(conditions, fmt.Sprintf(`b.user_id = $1 AND b.folder_id IN (
+			WITH RECURSIVE subfolders AS (
+				SELECT id FROM board_folders WHERE id = %s AND user_id = $1
+				UNION ALL
+				SELECT f.id FROM board_folders f JOIN subfolders s ON f.parent_id = s.id
+			)
+			SELECT id FROM subfolders
+		)`, arg(opts.FolderID)))
+	case opts.FolderID != "":
+		conditions = append(conditions, "b.user_id = $1 AND b.folder_id = "+arg(opts.FolderID))
+	}
+
+	for _, tag := range opts.Tags {
+		conditions = append(conditions,
+			"EXISTS (SELECT 1 FROM board_tags t WHERE t.board_id = b.id AND t.tag = "+arg(tag)+")")
+	}
+
+	dateColumn := "b.updated_at"
+	if opts.DateField == BoardSortCreated {
+		dateColumn = "b.created_at"
+	}
+	if opts.From != nil {
+		conditions = append(conditions, dateColumn+" >= "+arg(*opts.From))
+	}
+	if opts.To != nil {
+		conditions = append(conditions, dateColumn+" < "+arg(*opts.To))
+	}
+
+	var sortKey, sortCast string
+	switch opts.Sort {
+	case BoardSortName:
+		sortKey, sortCast = "LOWER(b.name)", "text"
+	case BoardSortCreated:
+		sortKey, sortCast = "b.created_at", "timestamptz"
+	default:
+		opts.Sort = BoardSortUpdated
+		sortKey, sortCast = "b.updated_at", "timestamptz"
+	}
+
+	direction, comparison := "ASC", ">"
+	if opts.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if opts.Cursor != "" {
+		cursor, err := decodeBoardCursor(opts.Cursor)
+		if err != nil || cursor.Sort != opts.Sort || cursor.Desc != opts.Desc {
+			return nil, "", ErrInvalidCursor
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, b.id) %s (%s::%s, %s::uuid)",
+			sortKey, comparison, arg(cursor.Value), sortCast, arg(cursor.ID)))
+	}
+
+	limit := ""
+	if opts.Limit > 0 {
+		limit = "LIMIT " + arg(opts.Limit+1)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			b.id,
+			b.name,
+			b.description,
+			b.user_id,
+			CASE WHEN b.user_id = $1 THEN 'owner' ELSE m.role END as role,
+			jsonb_array_length(b.data->'nodes') as node_count,
+			jsonb_array_length(b.data->'edges') as edge_count,
+			b.metrics,
+			b.created_at,
+			b.updated_at,
+			CASE WHEN b.user_id = $1 THEN b.folder_id END as folder_id,
+			COALESCE((SELECT array_agg(t.tag ORDER BY t.tag) FROM board_tags t WHERE t.board_id = b.id), '{}') as tags,
+			LOWER(b.name) as name_key
+		FROM boards b
+		LEFT JOIN board_members m ON m.board_id = b.id AND m.user_id = $1
+		WHERE %s
+		ORDER BY %s %s, b.id %s
+		%s
+	`, strings.Join(conditions, " AND "), sortKey, direction, direction, limit)
+
+	rows, err := b.db.Query(query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	boardList := []models.BoardListItem{}
+	var nameKeys []string
+	for rows.Next() {
+		var board models.BoardListItem
+		var folderID *string
+		var tags pq.StringArray
+		var nameKey string
+		var metricsJSON []byte
+		if err := rows.Scan(
+			&board.ID,
+			&board.Name,
+			&board.Description,
+			&board.OwnerID,
+			&board.Role,
+			&board.NodeCount,
+			&board.EdgeCount,
+			&metricsJSON,
+			&board.CreatedAt,
+			&board.UpdatedAt,
+			&folderID,
+			&tags,
+			&nameKey,
+		); err != nil {
+			return nil, "", err
+		}
+		board.FolderID = folderID
+		if metricsJSON != nil {
+			if err := json.Unmarshal(metricsJSON, &board.Metrics); err != nil {
+				return nil, "", err
+			}
+		}
+		board.Tags = []string(tags)
+		boardList = append(boardList, board)
+		nameKeys = append(nameKeys, nameKey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if err := b.fillFolderPaths(userID, boardList); err != nil {
+		return nil, "", err
+	}
+
+	// One extra row was fetched to find out whether there is another page
+	nextCursor := ""
+	if opts.Limit > 0 && len(boardList) > opts.Limit {
+		boardList = boardList[:opts.Limit]
+		last := boardList[len(boardList)-1]
+
+		cursor := boardCursor{Sort: opts.Sort, Desc: opts.Desc, ID: last.ID}
+		switch opts.Sort {
+		case BoardSortName:
+			cursor.Value = nameKeys[opts.Limit-1]
+		case BoardSortCreated:
+			cursor.Value = last.CreatedAt.Format(time.RFC3339Nano)
+		default:
+			cursor.Value = last.UpdatedAt.Format(time.RFC3339Nano)
+		}
+		nextCursor = encodeBoardCursor(cursor)
+	}
+
+	return boardList, nextCursor, nil
+}
+
+// fillFolderPaths sets FolderPath on boards filed in one of the user's folders
+func (b *BoardDB) fillFolderPaths(userID string, boards []models.BoardListItem) error {
+	filed := false
+	for _, board := range boards {
+		if board.FolderID != nil {
+			filed = true
+			break
+		}
+	}
+	if !filed {
+		return nil
+	}
+
+	folders, err := b.loadFolders(b.db, userID)
+	if err != nil {
+		return err
+	}
+
+	for i := range boards {
+		if boards[i].FolderID != nil {
+			boards[i].FolderPath = folderPath(folders, *boards[i].FolderID)
+		}
+	}
+
+	return nil
+}
+
+// encodeBoardCursor serializes a cursor into an opaque URL-safe string
+func encodeBoardCursor(cursor boardCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeBoardCursor parses a cursor produced by encodeBoardCursor
+func decodeBoardCursor(value string) (boardCursor, error) {
+	var cursor boardCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, err
+	}
+	if _, err := uuid.Parse(cursor.ID); err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if cursor.Sort != BoardSortName {
+		if _, err := time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+			return cursor, ErrInvalidCursor
+		}
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"saas-server/models"
+	"strings"
+)
+
+// ErrMemberUserNotFound is returned when inviting an email that has no account
+var ErrMemberUserNotFound = errors.New("no user with that email")
+
+// ErrMemberNotFound is returned when a user is not a member of the board
+var ErrMemberNotFound = errors.New("member not found")
+
+// ErrCannotShareWithOwner is returned when the owner tries to add themselves as a member
+var ErrCannotShareWithOwner = errors.New("the board owner cannot be added as a member")
+
+// IsValidMemberRole reports whether a role can be granted to a board member
+func IsValidMemberRole(role string) bool {
+	return role == models.BoardRoleEditor || role == models.BoardRoleViewer
+}
+
+// ListMembers retrieves the users a board is shared with. Any user with access
+// to the board may list its members.
+func (b *BoardDB) ListMembers(boardID, userID string) ([]models.BoardMember, error) {
+	if _, err := b.GetBoard(boardID, userID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT m.board_id, m.user_id, u.email, u.name, m.role,
+			COALESCE(m.invited_by::text, ''), m.created_at, m.updated_at
+		FROM board_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.board_id = $1
+		ORDER BY m.created_at ASC
+	`
+
+	rows, err := b.db.Query(query, boardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.BoardMember{}
+	for rows.Next() {
+		var member models.BoardMember
+		if err := rows.Scan(
+			&member.BoardID,
+			&member.UserID,
+			&member.Email,
+			&member.Name,
+			&member.Role,
+			&member.InvitedBy,
+			&member.CreatedAt,
+			&member.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// AddMember shares a board with the user registered under email. If the user
+// is already a member their role is updated. Only the owner may add members.
+func (b *BoardDB) AddMember(boardID, ownerID, email, role string) (*models.BoardMember, error) {
+	if err := b.requireOwner(boardID, ownerID); err != nil {
+		return nil, err
+	}
+
+	var memberID string
+	err := b.db.QueryRow(
+		`SELECT id FROM users WHERE LOWER(email) = LOWER($1)`,
+		strings.TrimSpace(email),
+	).Scan(&memberID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMemberUserNotFound
+		}
+		return nil, err
+	}
+
+	if memberID == ownerID {
+		return nil, ErrCannotShareWithOwner
+	}
+
+	query := `
+		INSERT INTO board_members (board_id, user_id, role, invited_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		ON CONFLICT (board_id, user_id)
+		DO UPDATE SET role = EXCLUDED.role, updated_at = NOW()
+	`
+	if _, err := b.db.Exec(query, boardID, memberID, role, ownerID); err != nil {
+		return nil, err
+	}
+
+	return b.getMember(boardID, memberID)
+}
+
+// UpdateMemberRole changes the role of an existing member. Only the owner may change roles.
+func (b *BoardDB) UpdateMemberRole(boardID, ownerID, memberID, role string) (*models.BoardMember, error) {
+	if err := b.requireOwner(boardID, ownerID); err != nil {
+		return nil, err
+	}
+
+	result, err := b.db.Exec(
+		`UPDATE board_members SET role = $1, updated_at = NOW() WHERE board_id = $2 AND user_id = $3`,
+		role, boardID, memberID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, ErrMemberNotFound
+	}
+
+	return b.getMember(boardID, memberID)
+}
+
+// RemoveMember revokes a member's access. The owner may remove anyone and a
+// member may remove themselves to leave a board.
+func (b *BoardDB) RemoveMember(boardID, userID, memberID string) error {
+	board, err := b.GetBoard(boardID, userID)
+	if err != nil {
+		return err
+	}
+
+	if board.Role != models.BoardRoleOwner && userID != memberID {
+		return ErrBoardPermissionDenied
+	}
+
+	result, err := b.db.Exec(
+		`DELETE FROM board_members WHERE board_id = $1 AND user_id = $2`,
+		boardID, memberID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrMemberNotFound
+	}
+
+	return nil
+}
+
+// requireOwner checks that the user owns the board
+func (b *BoardDB) requireOwner(boardID, userID string) error {
+	board, err := b.GetBoard(boardID, userID)
+	if err != nil {
+		return err
+	}
+
+	if board.Role != models.BoardRoleOwner {
+		return ErrBoardPermissionDenied
+	}
+
+	return nil
+}
+
+// getMember loads a single membership with the member's user details
+func (b *BoardDB) getMember(boardID, memberID string) (*models.BoardMember, error) {
+	query := `
+		SELECT m.board_id, m.user_id, u.email, u.name, m.role,
+			COALESCE(m.invited_by::text, ''), m.created_at, m.updated_at
+		FROM board_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.board_id = $1 AND m.user_id = $2
+	`
+
+	var member models.BoardMember
+	err := b.db.QueryRow(query, boardID, memberID).Scan(
+		&member.BoardID,
+		&member.UserID,
+		&member.Email,
+		&member.Name,
+		&member.Role,
+		&member.InvitedBy,
+		&member.CreatedAt,
+		&member.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMemberNotFound
+		}
+		return nil, err
+	}
+
+	return &member, nil
+}
@@ -0,0 +1,19 @@
+package database
+
+import (
+	"encoding/json"
+	"saas-server/models"
+	"saas-server/pkg/boardgraph"
+	"saas-server/pkg/boardmetrics"
+)
+
+// boardMetricsJSON computes the metrics summary cached in boards.metrics for
+// data that is about to be stored
+func boardMetricsJSON(data models.BoardData) ([]byte, error) {
+	graph, err := boardgraph.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(boardmetrics.Compute(graph).BoardMetrics)
+}
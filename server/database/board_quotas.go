@@ -0,0 +1,141 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"saas-server/models"
+)
+
+// ErrQuotaExceeded is wrapped by every QuotaError
+var ErrQuotaExceeded = errors.New("board quota exceeded")
+
+// Quota names used in QuotaError
+const (
+	QuotaBoards  = "boards"
+	QuotaNodes   = "nodes"
+	QuotaStorage = "storage"
+)
+
+// DefaultBoardLimits apply to users whose subscription variant has no row in
+// board_plan_limits, and to users without an active subscription
+var DefaultBoardLimits = models.BoardLimits{
+	MaxBoards:        50,
+	MaxNodesPerBoard: 1000,
+	MaxStorageBytes:  50 << 20,
+}
+
+// QuotaError is returned when a write would take a user past one of their plan's limits
+type QuotaError struct {
+	Quota     string
+	Limit     int64
+	Requested int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s quota exceeded: %d requested, limit is %d", e.Quota, e.Requested, e.Limit)
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// GetBoardQuota retrieves a user's board limits and current usage
+func (db *DB) GetBoardQuota(userID string) (*models.BoardQuota, error) {
+	return NewBoardDB(db.DB).BoardQuota(userID)
+}
+
+// BoardQuota retrieves a user's board limits and current usage
+func (b *BoardDB) BoardQuota(userID string) (*models.BoardQuota, error) {
+	limits, err := b.boardLimits(b.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var usage models.BoardUsage
+	err = b.db.QueryRow(
+		`SELECT COUNT(*) FILTER (WHERE deleted_at IS NULL), COALESCE(SUM(octet_length(data::text)), 0)
+		FROM boards WHERE user_id = $1`,
+		userID,
+	).Scan(&usage.Boards, &usage.StorageBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.BoardQuota{Limits: limits, Usage: usage}, nil
+}
+
+// boardLimits looks up the limits of the user's active subscription variant
+func (b *BoardDB) boardLimits(q queryRower, userID string) (models.BoardLimits, error) {
+	query := `
+		SELECT l.max_boards, l.max_nodes_per_board, l.max_storage_bytes
+		FROM subscriptions s
+		JOIN board_plan_limits l ON l.variant_id = s.variant_id
+		WHERE s.user_id = $1 AND s.status = 'active'
+		ORDER BY s.created_at DESC
+		LIMIT 1
+	`
+
+	var limits models.BoardLimits
+	err := q.QueryRow(query, userID).Scan(&limits.MaxBoards, &limits.MaxNodesPerBoard, &limits.MaxStorageBytes)
+	if err == sql.ErrNoRows {
+		return DefaultBoardLimits, nil
+	}
+	if err != nil {
+		return models.BoardLimits{}, err
+	}
+
+	return limits, nil
+}
+
+// checkBoardQuota verifies that ownerID stays within their plan once boardID
+// is stored. addsBoard is set when the board becomes a new live board (on
+// create or restore). dataJSON is the board's new content, or nil when the
+// content is not changing, in which case the node and storage limits are not
+// checked. It takes a per-owner lock held until tx ends, so concurrent
+// writes for the same owner cannot all pass the check against the same usage.
+func (b *BoardDB) checkBoardQuota(tx *sql.Tx, ownerID, boardID string, addsBoard bool, nodeCount int, dataJSON []byte) error {
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, ownerID); err != nil {
+		return err
+	}
+
+	limits, err := b.boardLimits(tx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if dataJSON != nil && limits.MaxNodesPerBoard > 0 && nodeCount > limits.MaxNodesPerBoard {
+		return &QuotaError{Quota: QuotaNodes, Limit: int64(limits.MaxNodesPerBoard), Requested: int64(nodeCount)}
+	}
+
+	// The board's stored size is measured the same way as the existing boards',
+	// as the text of the JSONB value, so usage adds up consistently
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE deleted_at IS NULL AND id <> $2),
+			COALESCE(SUM(octet_length(data::text)) FILTER (WHERE id <> $2), 0),
+			COALESCE(octet_length($3::jsonb::text), 0)
+		FROM boards
+		WHERE user_id = $1
+	`
+
+	var boards int
+	var otherBytes, boardBytes int64
+	var content interface{}
+	if dataJSON != nil {
+		content = dataJSON
+	}
+	if err := tx.QueryRow(query, ownerID, boardID, content).Scan(&boards, &otherBytes, &boardBytes); err != nil {
+		return err
+	}
+
+	if addsBoard && limits.MaxBoards > 0 && boards+1 > limits.MaxBoards {
+		return &QuotaError{Quota: QuotaBoards, Limit: int64(limits.MaxBoards), Requested: int64(boards + 1)}
+	}
+
+	if dataJSON != nil && limits.MaxStorageBytes > 0 && otherBytes+boardBytes > limits.MaxStorageBytes {
+		return &QuotaError{Quota: QuotaStorage, Limit: limits.MaxStorageBytes, Requested: otherBytes + boardBytes}
+	}
+
+	return nil
+}
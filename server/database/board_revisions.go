@@ -0,0 +1,216 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"saas-server/models"
+)
+
+// MaxBoardRevisions is the number of revisions kept per board; older ones are pruned
+const MaxBoardRevisions = 50
+
+// ErrRevisionNotFound is returned when a revision does not exist for the given board
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// recordRevision stores a snapshot of the board as it was before an update
+// and prunes revisions beyond MaxBoardRevisions. It must run inside the
+// transaction that performs the update so both succeed or fail together.
+func (b *BoardDB) recordRevision(tx *sql.Tx, board *models.Board, userID string) error {
+	dataJSON, err := json.Marshal(board.Data)
+	if err != nil {
+		return err
+	}
+
+	insertQuery := `
+		INSERT INTO board_revisions (board_id, user_id, name, description, data, created_at)
+		VALUES ($1, $2, $3, $4, $5, clock_timestamp())
+	`
+	if _, err := tx.Exec(insertQuery, board.ID, userID, board.Name, board.Description, dataJSON); err != nil {
+		return err
+	}
+
+	pruneQuery := `
+		DELETE FROM board_revisions
+		WHERE board_id = $1 AND id NOT IN (
+			SELECT id FROM board_revisions
+			WHERE board_id = $1
+			ORDER BY created_at DESC
+			LIMIT $2
+		)
+	`
+	_, err = tx.Exec(pruneQuery, board.ID, MaxBoardRevisions)
+	return err
+}
+
+// ListRevisions retrieves the revisions of a board, newest first
+func (b *BoardDB) ListRevisions(boardID, userID string) ([]models.BoardRevisionListItem, error) {
+	// Make sure the user can access the board before exposing its history
+	if _, err := b.GetBoard(boardID, userID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT
+			id,
+			board_id,
+			user_id,
+			name,
+			jsonb_array_length(data->'nodes') as node_count,
+			jsonb_array_length(data->'edges') as edge_count,
+			created_at
+		FROM board_revisions
+		WHERE board_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := b.db.Query(query, boardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.BoardRevisionListItem{}
+	for rows.Next() {
+		var revision models.BoardRevisionListItem
+		if err := rows.Scan(
+			&revision.ID,
+			&revision.BoardID,
+			&revision.UserID,
+			&revision.Name,
+			&revision.NodeCount,
+			&revision.EdgeCount,
+			&revision.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// GetRevision retrieves a single revision of a board the user can access
+func (b *BoardDB) GetRevision(boardID, revisionID, userID string) (*models.BoardRevision, error) {
+	if _, err := b.GetBoard(boardID, userID); err != nil {
+		return nil, err
+	}
+
+	return b.getRevision(b.db, boardID, revisionID)
+}
+
+// RestoreRevision replaces the board's content with that of a revision.
+// The current content is itself recorded as a revision first, so a restore
+// can be undone by restoring the revision it created.
+func (b *BoardDB) RestoreRevision(boardID, revisionID, userID string) (*models.Board, error) {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	existingBoard, err := b.lockBoard(tx, boardID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := b.getRevision(tx, boardID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Revisions may predate the current validation rules, so their content is
+	// cleaned like any other write before it replaces the board's
+	restoredData, err := validateBoardData("revision "+revisionID, revision.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.recordRevision(tx, existingBoard, userID); err != nil {
+		return nil, err
+	}
+
+	dataJSON, err := json.Marshal(restoredData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.checkBoardQuota(tx, existingBoard.UserID, boardID, false, len(restoredData.Nodes), dataJSON); err != nil {
+		return nil, err
+	}
+
+	metricsJSON, err := boardMetricsJSON(restoredData)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE boards
+		SET name = $1,
+			description = $2,
+			data = $3,
+			metrics = $4
+		WHERE id = $5
+	`
+	if _, err := tx.Exec(query, revision.Name, revision.Description, dataJSON, metricsJSON, boardID); err != nil {
+		return nil, err
+	}
+
+	if err := bumpBoardVersion(tx, boardID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return b.GetBoard(boardID, userID)
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// getRevision loads a revision scoped to its board
+func (b *BoardDB) getRevision(q queryRower, boardID, revisionID string) (*models.BoardRevision, error) {
+	query := `
+		SELECT id, board_id, user_id, name, COALESCE(description, ''), data, created_at
+		FROM board_revisions
+		WHERE id = $1 AND board_id = $2
+	`
+
+	var revision models.BoardRevision
+	var rawData []byte
+
+	err := q.QueryRow(query, revisionID, boardID).Scan(
+		&revision.ID,
+		&revision.BoardID,
+		&revision.UserID,
+		&revision.Name,
+		&revision.Description,
+		&rawData,
+		&revision.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rawData, &revision.Data); err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
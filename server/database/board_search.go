@@ -0,0 +1,94 @@
+package database
+
+import (
+	"saas-server/models"
+
+	"github.com/lib/pq"
+)
+
+// searchBoardsQuery ranks the boards a user can access against a web-style
+// search query, then finds the matching nodes and builds a headline for the
+// top results only. Highlights are wrapped in <mark> tags and HTML tags in
+// node content are stripped before the headline is built.
+const searchBoardsQuery = `
+	WITH ranked AS (
+		SELECT
+			b.id,
+			b.name,
+			COALESCE(b.description, '') AS description,
+			b.user_id,
+			CASE WHEN b.user_id = $1 THEN 'owner' ELSE m.role END AS role,
+			b.data,
+			b.updated_at,
+			ts_rank(b.search_vector, q.query) AS rank,
+			q.query
+		FROM boards b
+		CROSS JOIN (SELECT websearch_to_tsquery('english', $2) AS query) q
+		LEFT JOIN board_members m ON m.board_id = b.id AND m.user_id = $1
+		WHERE (b.user_id = $1 OR m.user_id IS NOT NULL)
+			AND b.deleted_at IS NULL
+			AND b.search_vector @@ q.query
+		ORDER BY rank DESC, b.updated_at DESC
+		LIMIT $3
+	)
+	SELECT
+		r.id,
+		r.name,
+		r.description,
+		r.user_id,
+		r.role,
+		r.rank,
+		COALESCE(nodes.ids, '{}'),
+		ts_headline('english',
+			regexp_replace(concat_ws(' ... ', r.name, NULLIF(r.description, ''), nodes.text), '<[^>]*>', ' ', 'g'),
+			r.query,
+			'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5'),
+		r.updated_at
+	FROM ranked r
+	LEFT JOIN LATERAL (
+		SELECT
+			array_agg(node->>'id' ORDER BY idx) FILTER (WHERE node->>'id' IS NOT NULL) AS ids,
+			string_agg(board_node_search_text(node), ' ... ' ORDER BY idx) AS text
+		FROM jsonb_array_elements(r.data->'nodes') WITH ORDINALITY AS n(node, idx)
+		WHERE to_tsvector('english', board_node_search_text(node)) @@ r.query
+	) nodes ON true
+	ORDER BY r.rank DESC, r.updated_at DESC
+`
+
+// SearchBoards runs a full-text search over the names, descriptions and node
+// labels and content of every board the user owns or that is shared with them.
+// Results are ordered by relevance and capped at limit.
+func (b *BoardDB) SearchBoards(userID, query string, limit int) ([]models.BoardSearchResult, error) {
+	rows, err := b.db.Query(searchBoardsQuery, userID, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.BoardSearchResult{}
+	for rows.Next() {
+		var result models.BoardSearchResult
+		var nodeIDs pq.StringArray
+		if err := rows.Scan(
+			&result.ID,
+			&result.Name,
+			&result.Description,
+			&result.OwnerID,
+			&result.Role,
+			&result.Rank,
+			&nodeIDs,
+			&result.Snippet,
+			&result.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		result.NodeIDs = []string(nodeIDs)
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,177 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"saas-server/models"
+	"time"
+)
+
+// ErrShareLinkNotFound is returned when a share token is unknown or has been revoked
+var ErrShareLinkNotFound = errors.New("share link not found")
+
+// ErrShareLinkExpired is returned when a share link is past its expiry
+var ErrShareLinkExpired = errors.New("share link has expired")
+
+// shareTokenBytes is the amount of randomness in a share token
+const shareTokenBytes = 32
+
+// CreateShareLink creates a public link to a board. Only the owner may share a
+// board publicly. passwordHash may be empty and expiresAt may be nil.
+func (b *BoardDB) CreateShareLink(boardID, ownerID, passwordHash string, expiresAt *time.Time) (*models.BoardShareLink, error) {
+	if err := b.requireOwner(boardID, ownerID); err != nil {
+		return nil, err
+	}
+
+	token, err := generateShareToken()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO board_share_links (board_id, token, password_hash, expires_at, created_by, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, NOW())
+		RETURNING id, board_id, token, COALESCE(password_hash, ''), expires_at, revoked_at, created_by, created_at
+	`
+
+	return scanShareLink(b.db.QueryRow(query, boardID, token, passwordHash, expiresAt, ownerID))
+}
+
+// ListShareLinks retrieves all share links of a board, including revoked ones
+func (b *BoardDB) ListShareLinks(boardID, ownerID string) ([]models.BoardShareLink, error) {
+	if err := b.requireOwner(boardID, ownerID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, board_id, token, COALESCE(password_hash, ''), expires_at, revoked_at, created_by, created_at
+		FROM board_share_links
+		WHERE board_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := b.db.Query(query, boardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []models.BoardShareLink{}
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, *link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// RevokeShareLink disables a share link so its token no longer works
+func (b *BoardDB) RevokeShareLink(boardID, ownerID, linkID string) error {
+	if err := b.requireOwner(boardID, ownerID); err != nil {
+		return err
+	}
+
+	result, err := b.db.Exec(
+		`UPDATE board_share_links SET revoked_at = NOW() WHERE id = $1 AND board_id = $2 AND revoked_at IS NULL`,
+		linkID, boardID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrShareLinkNotFound
+	}
+
+	return nil
+}
+
+// GetSharedBoard resolves a share token to its link and board without any
+// user check. Checking the link's password is left to the caller.
+func (b *BoardDB) GetSharedBoard(token string) (*models.BoardShareLink, *models.Board, error) {
+	query := `
+		SELECT id, board_id, token, COALESCE(password_hash, ''), expires_at, revoked_at, created_by, created_at
+		FROM board_share_links
+		WHERE token = $1 AND revoked_at IS NULL
+	`
+
+	link, err := scanShareLink(b.db.QueryRow(query, token))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if link.ExpiresAt != nil && link.ExpiresAt.Before(time.Now()) {
+		return nil, nil, ErrShareLinkExpired
+	}
+
+	boardQuery := `
+		SELECT id, user_id, name, description, data, version, forked_from, created_at, updated_at, 'viewer'
+		FROM boards
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	board, err := scanBoard(b.db.QueryRow(boardQuery, link.BoardID))
+	if err != nil {
+		if err == ErrBoardNotFound {
+			return nil, nil, ErrShareLinkNotFound
+		}
+		return nil, nil, err
+	}
+
+	return link, board, nil
+}
+
+// scanShareLink scans a share link selected with the standard column list
+func scanShareLink(row rowScanner) (*models.BoardShareLink, error) {
+	var link models.BoardShareLink
+	var expiresAt, revokedAt sql.NullTime
+
+	err := row.Scan(
+		&link.ID,
+		&link.BoardID,
+		&link.Token,
+		&link.PasswordHash,
+		&expiresAt,
+		&revokedAt,
+		&link.CreatedBy,
+		&link.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrShareLinkNotFound
+		}
+		return nil, err
+	}
+
+	link.HasPassword = link.PasswordHash != ""
+	if expiresAt.Valid {
+		link.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		link.RevokedAt = &revokedAt.Time
+	}
+
+	return &link, nil
+}
+
+// generateShareToken returns a URL-safe random token
+func generateShareToken() (string, error) {
+	buf := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
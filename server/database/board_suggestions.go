@@ -0,0 +1,214 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+
+	"saas-server/models"
+	"saas-server/pkg/boardgraph"
+	"saas-server/pkg/boardlayout"
+	"saas-server/pkg/boardpatch"
+
+	"github.com/google/uuid"
+)
+
+// suggestionLayout is the algorithm used to arrange applied suggestions
+// around their parent node
+const suggestionLayout = "tree"
+
+// suggestionBuilder accumulates the nodes and edges created for suggestions
+type suggestionBuilder struct {
+	nodes   []json.RawMessage
+	edges   []json.RawMessage
+	nodeIDs []string
+	edgeIDs []string
+}
+
+// ApplySuggestions adds AI suggestions to a board below the node parentID,
+// as the board editor does: a node for each category connected to the
+// parent, a node for each concept connected to its category and nodes for
+// sub-branches connected to their concept. Categories are added in
+// alphabetical order. The new nodes are laid out as a tree to the right of
+// the parent and moved down until none of them overlaps an existing node.
+// It returns the updated board with the IDs of the nodes and edges created,
+// so the client can undo the change by deleting them.
+func (b *BoardDB) ApplySuggestions(boardID, userID, parentID string, suggestions models.BrainstormSuggestions, expectedVersion int) (*models.Board, []string, []string, error) {
+	var created suggestionBuilder
+
+	board, err := b.patchBoard(boardID, userID, expectedVersion, func(data models.BoardData) (models.BoardPatchRequest, error) {
+		var patch models.BoardPatchRequest
+
+		graph, err := boardgraph.Parse(data)
+		if err != nil {
+			return patch, err
+		}
+		parent := graph.Node(parentID)
+		if parent == nil {
+			return patch, fmt.Errorf("%w: parent node %q does not exist", boardpatch.ErrInvalidPatch, parentID)
+		}
+
+		created = suggestionBuilder{}
+		categories := make([]string, 0, len(suggestions))
+		for category := range suggestions {
+			categories = append(categories, category)
+		}
+		sort.Strings(categories)
+
+		for _, category := range categories {
+			categoryID, err := created.addNode(parentID, category, "")
+			if err != nil {
+				return patch, err
+			}
+			if err := created.addConcepts(categoryID, suggestions[category]); err != nil {
+				return patch, err
+			}
+		}
+
+		parentRaw, _ := boardpatch.Find(data.Nodes, parentID)
+		nodes, err := placeSuggestions(graph, parent, parentRaw, created.nodes, created.edges)
+		if err != nil {
+			return patch, err
+		}
+
+		patch.Nodes.Add = nodes
+		patch.Edges.Add = created.edges
+		return patch, nil
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return board, created.nodeIDs, created.edgeIDs, nil
+}
+
+// addConcepts adds a node for each concept and its sub-branches below parentID
+func (s *suggestionBuilder) addConcepts(parentID string, concepts []models.BrainstormConcept) error {
+	for _, concept := range concepts {
+		conceptID, err := s.addNode(parentID, concept.Title, concept.Reason)
+		if err != nil {
+			return err
+		}
+		if err := s.addConcepts(conceptID, concept.SubBranches); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addNode adds a text node with the given label and plain text content,
+// connected to parentID, and returns its ID
+func (s *suggestionBuilder) addNode(parentID, label, text string) (string, error) {
+	nodeID := "node-" + uuid.New().String()
+	content := ""
+	if text != "" {
+		content = "<p>" + html.EscapeString(text) + "</p>"
+	}
+
+	node, err := json.Marshal(map[string]interface{}{
+		"id":       nodeID,
+		"type":     "textNode",
+		"position": boardlayout.Position{},
+		"data": map[string]interface{}{
+			"label": label,
+			"content": map[string]interface{}{
+				"text":   content,
+				"images": []string{},
+				"isHtml": true,
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	edgeID := "edge-" + uuid.New().String()
+	edge, err := json.Marshal(map[string]string{
+		"id":           edgeID,
+		"source":       parentID,
+		"target":       nodeID,
+		"type":         "default",
+		"sourceHandle": "right",
+		"targetHandle": "left",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.nodes = append(s.nodes, node)
+	s.edges = append(s.edges, edge)
+	s.nodeIDs = append(s.nodeIDs, nodeID)
+	s.edgeIDs = append(s.edgeIDs, edgeID)
+	return nodeID, nil
+}
+
+// placeSuggestions positions new nodes connected below parent. The subtree
+// is laid out on its own, anchored so the parent keeps its position, then
+// moved down past any existing node it would overlap. It returns the nodes
+// with their positions set.
+func placeSuggestions(graph *boardgraph.Graph, parent *boardgraph.Node, parentRaw json.RawMessage, nodes, edges []json.RawMessage) ([]json.RawMessage, error) {
+	algorithm, err := boardlayout.Lookup(suggestionLayout)
+	if err != nil {
+		return nil, err
+	}
+
+	subtree, err := boardgraph.Parse(models.BoardData{
+		Nodes: append([]json.RawMessage{parentRaw}, nodes...),
+		Edges: edges,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	positions := algorithm.Layout(subtree)
+	anchor := positions[parent.ID]
+	delete(positions, parent.ID)
+	for id, position := range positions {
+		positions[id] = boardlayout.Position{
+			X: position.X + parent.X - anchor.X,
+			Y: position.Y + parent.Y - anchor.Y,
+		}
+	}
+
+	// Each pass moves the new nodes below the lowest existing node they
+	// overlap, so the loop ends once they are clear of everything
+	for {
+		shift := 0.0
+		for id, position := range positions {
+			placed := subtree.Node(id)
+			for _, existing := range graph.Nodes {
+				if existing.ID == parent.ID || !overlaps(placed, position, existing) {
+					continue
+				}
+				_, height := boardlayout.NodeSize(existing)
+				if needed := existing.Y + height + boardlayout.SiblingGap - position.Y; needed > shift {
+					shift = needed
+				}
+			}
+		}
+		if shift == 0 {
+			break
+		}
+		for id, position := range positions {
+			positions[id] = boardlayout.Position{X: position.X, Y: position.Y + shift}
+		}
+	}
+
+	placed, err := boardlayout.Apply(models.BoardData{Nodes: nodes}, positions)
+	if err != nil {
+		return nil, err
+	}
+	return placed.Nodes, nil
+}
+
+// overlaps reports whether node placed at position would overlap existing,
+// leaving SiblingGap between them
+func overlaps(node *boardgraph.Node, position boardlayout.Position, existing *boardgraph.Node) bool {
+	width, height := boardlayout.NodeSize(node)
+	existingWidth, existingHeight := boardlayout.NodeSize(existing)
+	gap := float64(boardlayout.SiblingGap)
+
+	return position.X < existing.X+existingWidth+gap && existing.X < position.X+width+gap &&
+		position.Y < existing.Y+existingHeight+gap && existing.Y < position.Y+height+gap
+}
@@ -0,0 +1,227 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"saas-server/models"
+)
+
+// ErrTemplateNotFound is returned when a template does not exist or is another user's private template
+var ErrTemplateNotFound = errors.New("template not found")
+
+// templateColumns is the column list read by queryTemplates and GetTemplate, without data
+const templateColumns = `
+	id, name, description, category, user_id IS NULL,
+	COALESCE(jsonb_array_length(data->'nodes'), 0), COALESCE(jsonb_array_length(data->'edges'), 0),
+	created_at, updated_at
+`
+
+// ListTemplates retrieves the system templates and the user's own templates,
+// system templates first
+func (b *BoardDB) ListTemplates(userID string) ([]models.BoardTemplate, error) {
+	return b.queryTemplates(
+		`SELECT `+templateColumns+` FROM board_templates
+		WHERE user_id IS NULL OR user_id = $1
+		ORDER BY user_id IS NOT NULL, category, name`,
+		userID,
+	)
+}
+
+// ListSystemTemplates retrieves the templates published by admins
+func (b *BoardDB) ListSystemTemplates() ([]models.BoardTemplate, error) {
+	return b.queryTemplates(
+		`SELECT ` + templateColumns + ` FROM board_templates
+		WHERE user_id IS NULL
+		ORDER BY category, name`,
+	)
+}
+
+// GetTemplate retrieves a template with its data if it is a system template
+// or belongs to the user. An empty userID only matches system templates.
+func (b *BoardDB) GetTemplate(templateID, userID string) (*models.BoardTemplate, error) {
+	var owner interface{}
+	if userID != "" {
+		owner = userID
+	}
+
+	row := b.db.QueryRow(
+		`SELECT `+templateColumns+`, data FROM board_templates
+		WHERE id = $1 AND (user_id IS NULL OR user_id = $2)`,
+		templateID, owner,
+	)
+
+	var template models.BoardTemplate
+	var rawData []byte
+	err := row.Scan(
+		&template.ID,
+		&template.Name,
+		&template.Description,
+		&template.Category,
+		&template.System,
+		&template.NodeCount,
+		&template.EdgeCount,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+		&rawData,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+
+	var data models.BoardData
+	if err := json.Unmarshal(rawData, &data); err != nil {
+		return nil, err
+	}
+	template.Data = &data
+
+	return &template, nil
+}
+
+// SaveBoardAsTemplate stores a copy of a board the user can read as one of
+// the user's private templates
+func (b *BoardDB) SaveBoardAsTemplate(boardID, userID string, req models.BoardTemplateRequest) (*models.BoardTemplate, error) {
+	board, err := b.GetBoard(boardID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.createTemplate(&userID, req, board.Data)
+}
+
+// CreateSystemTemplate publishes a template visible to every user
+func (b *BoardDB) CreateSystemTemplate(req models.BoardTemplateRequest, data models.BoardData) (*models.BoardTemplate, error) {
+	return b.createTemplate(nil, req, data)
+}
+
+// UpdateSystemTemplate changes the details of a system template, and its
+// content if data is not nil
+func (b *BoardDB) UpdateSystemTemplate(templateID string, req models.BoardTemplateRequest, data *models.BoardData) (*models.BoardTemplate, error) {
+	var dataJSON interface{}
+	if data != nil {
+		validData, err := validateBoardData("template "+templateID, *data)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := json.Marshal(validData)
+		if err != nil {
+			return nil, err
+		}
+		dataJSON = encoded
+	}
+
+	result, err := b.db.Exec(
+		`UPDATE board_templates
+		SET name = $1, description = $2, category = $3, data = COALESCE($4, data), updated_at = NOW()
+		WHERE id = $5 AND user_id IS NULL`,
+		req.Name, req.Description, req.Category, dataJSON, templateID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, ErrTemplateNotFound
+	}
+
+	return b.GetTemplate(templateID, "")
+}
+
+// DeleteTemplate deletes one of the user's own templates
+func (b *BoardDB) DeleteTemplate(templateID, userID string) error {
+	return b.deleteTemplate(`DELETE FROM board_templates WHERE id = $1 AND user_id = $2`, templateID, userID)
+}
+
+// DeleteSystemTemplate deletes a system template
+func (b *BoardDB) DeleteSystemTemplate(templateID string) error {
+	return b.deleteTemplate(`DELETE FROM board_templates WHERE id = $1 AND user_id IS NULL`, templateID)
+}
+
+// createTemplate inserts a template owned by ownerID, or a system template when ownerID is nil
+func (b *BoardDB) createTemplate(ownerID *string, req models.BoardTemplateRequest, data models.BoardData) (*models.BoardTemplate, error) {
+	validData, err := validateBoardData("new template", data)
+	if err != nil {
+		return nil, err
+	}
+
+	dataJSON, err := json.Marshal(validData)
+	if err != nil {
+		return nil, err
+	}
+
+	var templateID string
+	err = b.db.QueryRow(
+		`INSERT INTO board_templates (user_id, name, description, category, data, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		RETURNING id`,
+		ownerID, req.Name, req.Description, req.Category, dataJSON,
+	).Scan(&templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	userID := ""
+	if ownerID != nil {
+		userID = *ownerID
+	}
+	return b.GetTemplate(templateID, userID)
+}
+
+// deleteTemplate runs a delete statement and maps "no rows" to ErrTemplateNotFound
+func (b *BoardDB) deleteTemplate(query string, args ...interface{}) error {
+	result, err := b.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrTemplateNotFound
+	}
+
+	return nil
+}
+
+// queryTemplates runs a query selecting templateColumns
+func (b *BoardDB) queryTemplates(query string, args ...interface{}) ([]models.BoardTemplate, error) {
+	rows, err := b.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []models.BoardTemplate{}
+	for rows.Next() {
+		var template models.BoardTemplate
+		if err := rows.Scan(
+			&template.ID,
+			&template.Name,
+			&template.Description,
+			&template.Category,
+			&template.System,
+			&template.NodeCount,
+			&template.EdgeCount,
+			&template.CreatedAt,
+			&template.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"log"
+	"os"
+	"saas-server/models"
+	"strconv"
+	"time"
+)
+
+// DefaultBoardTrashRetention is how long boards stay in the trash when
+// BOARD_TRASH_RETENTION_DAYS is not set
+const DefaultBoardTrashRetention = 30 * 24 * time.Hour
+
+// BoardTrashRetention returns how long trashed boards are kept before they are
+// purged, read from BOARD_TRASH_RETENTION_DAYS
+func BoardTrashRetention() time.Duration {
+	value := os.Getenv("BOARD_TRASH_RETENTION_DAYS")
+	if value == "" {
+		return DefaultBoardTrashRetention
+	}
+
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 1 {
+		log.Printf("Invalid BOARD_TRASH_RETENTION_DAYS %q, using default", value)
+		return DefaultBoardTrashRetention
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// ListTrashedBoards retrieves the boards a user owns that are in the trash,
+// most recently deleted first. PurgeAt is filled in from retention.
+func (b *BoardDB) ListTrashedBoards(userID string, retention time.Duration) ([]models.BoardListItem, error) {
+	query := `
+		SELECT
+			id,
+			name,
+			description,
+			user_id,
+			jsonb_array_length(data->'nodes') as node_count,
+			jsonb_array_length(data->'edges') as edge_count,
+			created_at,
+			updated_at,
+			deleted_at
+		FROM boards
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`
+
+	rows, err := b.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	boardList := []models.BoardListItem{}
+	for rows.Next() {
+		var board models.BoardListItem
+		var deletedAt time.Time
+		if err := rows.Scan(
+			&board.ID,
+			&board.Name,
+			&board.Description,
+			&board.OwnerID,
+			&board.NodeCount,
+			&board.EdgeCount,
+			&board.CreatedAt,
+			&board.UpdatedAt,
+			&deletedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		purgeAt := deletedAt.Add(retention)
+		board.Role = models.BoardRoleOwner
+		board.DeletedAt = &deletedAt
+		board.PurgeAt = &purgeAt
+		boardList = append(boardList, board)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return boardList, nil
+}
+
+// RestoreBoard takes a board out of the trash. Only the owner may restore a board.
+func (b *BoardDB) RestoreBoard(boardID, userID string) (*models.Board, error) {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// A restored board counts towards the board limit again
+	if err := b.checkBoardQuota(tx, userID, boardID, true, 0, nil); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(
+		`UPDATE boards SET deleted_at = NULL WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL`,
+		boardID, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, ErrBoardNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return b.GetBoard(boardID, userID)
+}
+
+// PurgeBoard permanently deletes a board that is in the trash, along with its
+// revisions, members and share links. Only the owner may purge a board.
+func (b *BoardDB) PurgeBoard(boardID, userID string) error {
+	result, err := b.db.Exec(
+		`DELETE FROM boards WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL`,
+		boardID, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrBoardNotFound
+	}
+
+	return nil
+}
+
+// PurgeTrashedBoards permanently deletes every board that was moved to the
+// trash before cutoff and returns how many were deleted
+func (b *BoardDB) PurgeTrashedBoards(cutoff time.Time) (int64, error) {
+	result, err := b.db.Exec(`DELETE FROM boards WHERE deleted_at IS NOT NULL AND deleted_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -49,6 +49,7 @@ type DBInterface interface {
 
 	// Subscription operations
 	GetSubscriptionByUserID(userID string) (*models.Subscription, error)
+	GetBoardQuota(userID string) (*models.BoardQuota, error)
 
 	// Additional operations
 	CreateOrder(userID string, orderID int, customerID int, productID int, variantID int, status string, subtotalFormatted string, taxFormatted string, totalFormatted string, taxInclusive bool) error
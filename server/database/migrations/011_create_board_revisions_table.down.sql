@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_board_revisions_board_id_created_at;
+
+-- Drop table
+DROP TABLE IF EXISTS board_revisions;
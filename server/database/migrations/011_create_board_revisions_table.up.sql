@@ -0,0 +1,17 @@
+-- Create board_revisions table for storing snapshots of board content
+CREATE TABLE IF NOT EXISTS board_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    board_id UUID NOT NULL REFERENCES boards(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    data JSONB NOT NULL DEFAULT '{"nodes": [], "edges": []}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Add index for listing a board's revisions newest first
+CREATE INDEX IF NOT EXISTS idx_board_revisions_board_id_created_at ON board_revisions(board_id, created_at DESC);
+
+-- Add a comment to the table
+COMMENT ON TABLE board_revisions IS 'Stores the previous content of a board each time it is updated';
+COMMENT ON COLUMN board_revisions.user_id IS 'UUID of the user whose update replaced this revision';
@@ -0,0 +1,2 @@
+-- Drop version column
+ALTER TABLE boards DROP COLUMN IF EXISTS version;
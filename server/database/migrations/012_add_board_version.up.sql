@@ -0,0 +1,4 @@
+-- Add a version counter used for optimistic concurrency control on board updates
+ALTER TABLE boards ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN boards.version IS 'Incremented on every content change; exposed to clients as the ETag';
@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_board_members_user_id;
+
+-- Drop table
+DROP TABLE IF EXISTS board_members;
@@ -0,0 +1,17 @@
+-- Create board_members table for sharing boards with other users
+CREATE TABLE IF NOT EXISTS board_members (
+    board_id UUID NOT NULL REFERENCES boards(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('editor', 'viewer')),
+    invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (board_id, user_id)
+);
+
+-- Add index for listing the boards shared with a user
+CREATE INDEX IF NOT EXISTS idx_board_members_user_id ON board_members(user_id);
+
+-- Add a comment to the table
+COMMENT ON TABLE board_members IS 'Users a board is shared with; the owner is boards.user_id and is not listed here';
+COMMENT ON COLUMN board_members.role IS 'editor can change content, viewer can only read';
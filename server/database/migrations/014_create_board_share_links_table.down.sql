@@ -0,0 +1,6 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_board_share_links_board_id;
+DROP INDEX IF EXISTS idx_board_share_links_token;
+
+-- Drop table
+DROP TABLE IF EXISTS board_share_links;
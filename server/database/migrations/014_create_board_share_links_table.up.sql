@@ -0,0 +1,19 @@
+-- Create board_share_links table for public read-only links to boards
+CREATE TABLE IF NOT EXISTS board_share_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    board_id UUID NOT NULL REFERENCES boards(id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    password_hash VARCHAR(255),
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Add indexes for faster lookups
+CREATE INDEX IF NOT EXISTS idx_board_share_links_board_id ON board_share_links(board_id);
+CREATE INDEX IF NOT EXISTS idx_board_share_links_token ON board_share_links(token);
+
+-- Add a comment to the table
+COMMENT ON TABLE board_share_links IS 'Unguessable tokens granting anonymous read-only access to a board';
+COMMENT ON COLUMN board_share_links.password_hash IS 'Optional bcrypt hash required to open the link';
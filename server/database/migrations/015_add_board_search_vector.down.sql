@@ -0,0 +1,7 @@
+-- Drop index and column
+DROP INDEX IF EXISTS idx_boards_search_vector;
+ALTER TABLE boards DROP COLUMN IF EXISTS search_vector;
+
+-- Drop helper functions
+DROP FUNCTION IF EXISTS board_nodes_search_text(JSONB);
+DROP FUNCTION IF EXISTS board_node_search_text(JSONB);
@@ -0,0 +1,33 @@
+-- Plain text of a single node used for full-text search: its label plus its
+-- content, which is either a string or an object with a text field
+CREATE OR REPLACE FUNCTION board_node_search_text(node JSONB)
+RETURNS TEXT AS $$
+    SELECT concat_ws(' ',
+        node->'data'->>'label',
+        CASE jsonb_typeof(node->'data'->'content')
+            WHEN 'string' THEN node->'data'->>'content'
+            WHEN 'object' THEN node->'data'->'content'->>'text'
+        END
+    );
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Plain text of every node on a board
+CREATE OR REPLACE FUNCTION board_nodes_search_text(data JSONB)
+RETURNS TEXT AS $$
+    SELECT COALESCE(string_agg(board_node_search_text(node), ' '), '')
+    FROM jsonb_array_elements(
+        CASE WHEN jsonb_typeof(data->'nodes') = 'array' THEN data->'nodes' ELSE '[]'::jsonb END
+    ) AS node;
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Searchable document for each board, ranked name > description > nodes
+ALTER TABLE boards ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', COALESCE(name, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(description, '')), 'B') ||
+        setweight(to_tsvector('english', board_nodes_search_text(data)), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_boards_search_vector ON boards USING GIN (search_vector);
+
+COMMENT ON COLUMN boards.search_vector IS 'Full-text index of the name, description and node labels and content';
@@ -0,0 +1,3 @@
+-- Drop index and column
+DROP INDEX IF EXISTS idx_boards_deleted_at;
+ALTER TABLE boards DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,7 @@
+-- Soft delete for boards: deleted boards stay in the trash until restored or purged
+ALTER TABLE boards ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Add index for listing the trash and finding boards to purge
+CREATE INDEX IF NOT EXISTS idx_boards_deleted_at ON boards(deleted_at) WHERE deleted_at IS NOT NULL;
+
+COMMENT ON COLUMN boards.deleted_at IS 'When the board was moved to the trash; NULL for live boards';
@@ -0,0 +1,11 @@
+-- Drop list indexes
+DROP INDEX IF EXISTS idx_boards_user_id_lower_name;
+DROP INDEX IF EXISTS idx_boards_user_id_updated_at;
+
+-- Drop tags
+DROP TABLE IF EXISTS board_tags;
+
+-- Drop folder column and folders
+DROP INDEX IF EXISTS idx_boards_folder_id;
+ALTER TABLE boards DROP COLUMN IF EXISTS folder_id;
+DROP TABLE IF EXISTS board_folders;
@@ -0,0 +1,43 @@
+-- Create board_folders table; folders belong to a user and can be nested
+CREATE TABLE IF NOT EXISTS board_folders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id UUID REFERENCES board_folders(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Add indexes for listing folders and their children
+CREATE INDEX IF NOT EXISTS idx_board_folders_user_id ON board_folders(user_id);
+CREATE INDEX IF NOT EXISTS idx_board_folders_parent_id ON board_folders(parent_id);
+
+-- Folder names are unique among siblings, ignoring case
+CREATE UNIQUE INDEX IF NOT EXISTS idx_board_folders_unique_name ON board_folders (
+    user_id,
+    COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'::uuid),
+    LOWER(name)
+);
+
+-- Boards can be filed in one of their owner's folders; deleting a folder moves its boards to the top level
+ALTER TABLE boards ADD COLUMN IF NOT EXISTS folder_id UUID REFERENCES board_folders(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_boards_folder_id ON boards(folder_id);
+
+-- Create board_tags table for free-form labels on boards
+CREATE TABLE IF NOT EXISTS board_tags (
+    board_id UUID NOT NULL REFERENCES boards(id) ON DELETE CASCADE,
+    tag VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (board_id, tag)
+);
+
+-- Add index for filtering boards by tag
+CREATE INDEX IF NOT EXISTS idx_board_tags_tag ON board_tags(tag);
+
+-- Add indexes for the sort orders offered by the board list
+CREATE INDEX IF NOT EXISTS idx_boards_user_id_updated_at ON boards(user_id, updated_at DESC, id);
+CREATE INDEX IF NOT EXISTS idx_boards_user_id_lower_name ON boards(user_id, LOWER(name), id);
+
+-- Add comments to the tables
+COMMENT ON TABLE board_folders IS 'Nested folders a user files their boards in';
+COMMENT ON TABLE board_tags IS 'Lower-case tags attached to boards';
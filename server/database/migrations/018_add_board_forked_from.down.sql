@@ -0,0 +1,3 @@
+-- Drop index and column
+DROP INDEX IF EXISTS idx_boards_forked_from;
+ALTER TABLE boards DROP COLUMN IF EXISTS forked_from;
@@ -0,0 +1,7 @@
+-- Record which board a duplicate was made from
+ALTER TABLE boards ADD COLUMN IF NOT EXISTS forked_from UUID REFERENCES boards(id) ON DELETE SET NULL;
+
+-- Add index for finding the copies of a board
+CREATE INDEX IF NOT EXISTS idx_boards_forked_from ON boards(forked_from) WHERE forked_from IS NOT NULL;
+
+COMMENT ON COLUMN boards.forked_from IS 'Board this board was duplicated from; NULL once the original is purged';
@@ -0,0 +1,6 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_board_templates_system;
+DROP INDEX IF EXISTS idx_board_templates_user_id;
+
+-- Drop table
+DROP TABLE IF EXISTS board_templates;
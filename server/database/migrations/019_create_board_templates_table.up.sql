@@ -0,0 +1,18 @@
+-- Create board_templates table; templates without an owner are system templates published by admins
+CREATE TABLE IF NOT EXISTS board_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    category VARCHAR(50) NOT NULL DEFAULT '',
+    data JSONB NOT NULL DEFAULT '{"nodes": [], "edges": []}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Add indexes for listing a user's templates and the system templates
+CREATE INDEX IF NOT EXISTS idx_board_templates_user_id ON board_templates(user_id);
+CREATE INDEX IF NOT EXISTS idx_board_templates_system ON board_templates(category, name) WHERE user_id IS NULL;
+
+-- Add a comment to the table
+COMMENT ON TABLE board_templates IS 'Starting points for new boards; user_id is NULL for system templates';
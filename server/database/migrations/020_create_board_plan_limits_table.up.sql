@@ -0,0 +1,14 @@
+-- Create board_plan_limits table; board quotas per subscription variant, where 0 means unlimited.
+-- Variants without a row get the defaults compiled into the server.
+CREATE TABLE IF NOT EXISTS board_plan_limits (
+    variant_id INTEGER PRIMARY KEY,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    max_boards INTEGER NOT NULL DEFAULT 0 CHECK (max_boards >= 0),
+    max_nodes_per_board INTEGER NOT NULL DEFAULT 0 CHECK (max_nodes_per_board >= 0),
+    max_storage_bytes BIGINT NOT NULL DEFAULT 0 CHECK (max_storage_bytes >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Add a comment to the table
+COMMENT ON TABLE board_plan_limits IS 'Board quotas for each LemonSqueezy variant; 0 means unlimited';
@@ -0,0 +1,2 @@
+-- Drop cached metrics
+ALTER TABLE boards DROP COLUMN IF EXISTS metrics;
@@ -0,0 +1,4 @@
+-- Cache a summary of each board's structure for board lists
+ALTER TABLE boards ADD COLUMN IF NOT EXISTS metrics JSONB;
+
+COMMENT ON COLUMN boards.metrics IS 'Structure metrics computed when the board data was last saved; NULL until then';
@@ -0,0 +1,6 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_board_comments_parent_id;
+DROP INDEX IF EXISTS idx_board_comments_board_node;
+
+-- Drop table
+DROP TABLE IF EXISTS board_comments;
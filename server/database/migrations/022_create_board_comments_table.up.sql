@@ -0,0 +1,22 @@
+-- Create board_comments table for discussing a node without editing it
+CREATE TABLE IF NOT EXISTS board_comments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    board_id UUID NOT NULL REFERENCES boards(id) ON DELETE CASCADE,
+    node_id VARCHAR(255) NOT NULL,
+    parent_id UUID REFERENCES board_comments(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    mentions UUID[] NOT NULL DEFAULT '{}',
+    resolved_at TIMESTAMPTZ,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Add indexes for listing a node's threads and the replies of a thread
+CREATE INDEX IF NOT EXISTS idx_board_comments_board_node ON board_comments(board_id, node_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_board_comments_parent_id ON board_comments(parent_id) WHERE parent_id IS NOT NULL;
+
+-- Add a comment to the table
+COMMENT ON TABLE board_comments IS 'Discussion threads on board nodes; replies have a parent_id and only top-level comments are resolved';
+COMMENT ON COLUMN board_comments.mentions IS 'Users with access to the board who were @mentioned and emailed';
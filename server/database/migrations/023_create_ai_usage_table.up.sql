@@ -0,0 +1,21 @@
+-- Create ai_usage table; one row for every AI provider request made on a user's behalf
+CREATE TABLE IF NOT EXISTS ai_usage (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    model VARCHAR(255) NOT NULL DEFAULT '',
+    operation VARCHAR(50) NOT NULL,
+    prompt_tokens INTEGER NOT NULL DEFAULT 0 CHECK (prompt_tokens >= 0),
+    completion_tokens INTEGER NOT NULL DEFAULT 0 CHECK (completion_tokens >= 0),
+    latency_ms INTEGER NOT NULL DEFAULT 0 CHECK (latency_ms >= 0),
+    outcome VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Add indexes for a user's monthly usage and the admin report
+CREATE INDEX IF NOT EXISTS idx_ai_usage_user_created ON ai_usage(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_ai_usage_created_at ON ai_usage(created_at);
+
+-- Add a comment to the table
+COMMENT ON TABLE ai_usage IS 'AI provider requests made with users'' saved keys, for usage reports and plan limits';
+COMMENT ON COLUMN ai_usage.outcome IS 'success, error, invalid_response or cancelled';
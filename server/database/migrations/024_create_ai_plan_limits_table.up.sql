@@ -0,0 +1,13 @@
+-- Create ai_plan_limits table; monthly AI limits per subscription variant, where 0 means unlimited.
+-- Variants without a row get the defaults compiled into the server.
+CREATE TABLE IF NOT EXISTS ai_plan_limits (
+    variant_id INTEGER PRIMARY KEY,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    max_requests_per_month INTEGER NOT NULL DEFAULT 0 CHECK (max_requests_per_month >= 0),
+    max_tokens_per_month BIGINT NOT NULL DEFAULT 0 CHECK (max_tokens_per_month >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Add a comment to the table
+COMMENT ON TABLE ai_plan_limits IS 'Monthly AI request and token limits for each LemonSqueezy variant; 0 means unlimited';
@@ -0,0 +1,7 @@
+-- Restore the trigger that touches updated_at on every update
+DROP TRIGGER IF EXISTS trg_boards_updated_at ON boards;
+
+CREATE TRIGGER trg_boards_updated_at
+BEFORE UPDATE ON boards
+FOR EACH ROW
+EXECUTE FUNCTION update_boards_updated_at();
@@ -0,0 +1,13 @@
+-- Only touch updated_at when a board's content changes. Moving a board to a
+-- folder, trashing or restoring it and bumping its version leave it as is.
+DROP TRIGGER IF EXISTS trg_boards_updated_at ON boards;
+
+CREATE TRIGGER trg_boards_updated_at
+BEFORE UPDATE OF name, description, data ON boards
+FOR EACH ROW
+WHEN (
+    OLD.name IS DISTINCT FROM NEW.name
+    OR OLD.description IS DISTINCT FROM NEW.description
+    OR OLD.data IS DISTINCT FROM NEW.data
+)
+EXECUTE FUNCTION update_boards_updated_at();
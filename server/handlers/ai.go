@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"saas-server/database"
+	"saas-server/middleware"
+	"saas-server/models"
+	"saas-server/pkg/ai"
+	"saas-server/pkg/boardgraph"
+	"saas-server/pkg/encryption"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxBrainstormMessage is the longest brainstorm request accepted, in characters
+	maxBrainstormMessage = 2000
+	// invalidSuggestionsMessage is sent when a reply is not valid brainstorm JSON
+	invalidSuggestionsMessage = "The AI response was not in the expected format. Please try again."
+)
+
+// errNoProviderKey is returned when the user has not saved a key for a provider
+var errNoProviderKey = errors.New("no API key saved for provider")
+
+// AIHandler serves AI requests made on behalf of a user with the provider
+// keys saved in their settings. Providers are looked up in the ai registry.
+// Keys are decrypted only on the server and are never included in responses.
+// Every provider request is recorded in ai_usage.
+type AIHandler struct {
+	DB *database.DB
+}
+
+// NewAIHandler creates a new AIHandler instance
+func NewAIHandler(db *database.DB) *AIHandler {
+	return &AIHandler{DB: db}
+}
+
+// brainstormCall is a validated brainstorm request ready to send to a provider
+type brainstormCall struct {
+	userID   string
+	provider ai.Provider
+	apiKey   string
+	request  ai.Request
+}
+
+// Brainstorm handles POST /api/ai/brainstorm. It asks the selected provider
+// for ideas around a board node, using the node's ancestors as context, and
+// returns the suggestions once they are validated.
+func (h *AIHandler) Brainstorm(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[AIHandler] Brainstorm - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	call, ok := h.prepareBrainstorm(w, r)
+	if !ok {
+		return
+	}
+
+	started := time.Now()
+	completion, err := call.provider.Complete(r.Context(), call.apiKey, call.request)
+	if err != nil {
+		h.recordCall(call, models.AIOperationBrainstorm, started, nil, failedOutcome(r))
+		writeAIError(w, call.provider.Name(), err)
+		return
+	}
+
+	suggestions, err := ai.ParseSuggestions(completion.Text)
+	if err != nil {
+		h.recordCall(call, models.AIOperationBrainstorm, started, completion, models.AIOutcomeInvalidResponse)
+		log.Printf("[AIHandler] Rejected %s response: %v", call.provider.Name(), err)
+		http.Error(w, invalidSuggestionsMessage, http.StatusBadGateway)
+		return
+	}
+	h.recordCall(call, models.AIOperationBrainstorm, started, completion, models.AIOutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.BrainstormResponse{
+		Provider:    call.provider.Name(),
+		Model:       call.request.Model,
+		Suggestions: suggestions,
+	})
+}
+
+// StreamBrainstorm handles POST /api/ai/brainstorm/stream. It takes the same
+// request as Brainstorm and answers with a Server-Sent Events stream: a
+// "start" event, a "token" event for each piece of text from the model, a
+// "concept" event as soon as each concept is complete, and finally "done"
+// with the validated suggestions or "error". Closing the connection cancels
+// the provider request.
+func (h *AIHandler) StreamBrainstorm(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[AIHandler] StreamBrainstorm - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		log.Printf("[AIHandler] Streaming not supported by response writer")
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	call, ok := h.prepareBrainstorm(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event string, payload interface{}) error {
+		if err := writeAIEvent(w, event, payload); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	if err := send("start", map[string]string{
+		"provider": call.provider.Name(),
+		"model":    call.request.Model,
+	}); err != nil {
+		return
+	}
+
+	var parser ai.SuggestionStream
+	started := time.Now()
+	completion, err := call.provider.Stream(r.Context(), call.apiKey, call.request, func(text string) error {
+		if err := send("token", map[string]string{"text": text}); err != nil {
+			return err
+		}
+		for _, concept := range parser.Feed(text) {
+			if err := send("concept", concept); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if r.Context().Err() != nil {
+		h.recordCall(call, models.AIOperationBrainstormStream, started, completion, models.AIOutcomeCancelled)
+		log.Printf("[AIHandler] Brainstorm stream cancelled by the client")
+		return
+	}
+	if err != nil {
+		h.recordCall(call, models.AIOperationBrainstormStream, started, completion, models.AIOutcomeError)
+		log.Printf("[AIHandler] %s stream failed: %v", call.provider.Name(), err)
+		_, message := aiErrorResponse(err)
+		send("error", map[string]string{"message": message})
+		return
+	}
+
+	suggestions, err := ai.ParseSuggestions(parser.Text())
+	if err != nil {
+		h.recordCall(call, models.AIOperationBrainstormStream, started, completion, models.AIOutcomeInvalidResponse)
+		log.Printf("[AIHandler] Rejected %s response: %v", call.provider.Name(), err)
+		send("error", map[string]string{"message": invalidSuggestionsMessage})
+		return
+	}
+	h.recordCall(call, models.AIOperationBrainstormStream, started, completion, models.AIOutcomeSuccess)
+
+	send("done", models.BrainstormResponse{
+		Provider:    call.provider.Name(),
+		Model:       call.request.Model,
+		Suggestions: suggestions,
+	})
+}
+
+// prepareBrainstorm validates a brainstorm request, loads the board and the
+// user's key and builds the prompt. It writes an error response and returns
+// false if the request cannot be made.
+func (h *AIHandler) prepareBrainstorm(w http.ResponseWriter, r *http.Request) (*brainstormCall, bool) {
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[AIHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	var req models.BrainstormRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	req.Message = strings.TrimSpace(req.Message)
+	switch {
+	case req.BoardID == "" || req.NodeID == "":
+		http.Error(w, "Board ID and node ID are required", http.StatusBadRequest)
+		return nil, false
+	case req.Message == "":
+		http.Error(w, "Message is required", http.StatusBadRequest)
+		return nil, false
+	case utf8.RuneCountInString(req.Message) > maxBrainstormMessage:
+		http.Error(w, fmt.Sprintf("Message must be at most %d characters", maxBrainstormMessage), http.StatusBadRequest)
+		return nil, false
+	}
+	if _, err := uuid.Parse(req.BoardID); err != nil {
+		http.Error(w, "Invalid Board ID format", http.StatusBadRequest)
+		return nil, false
+	}
+	provider, err := ai.Lookup(req.Provider)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Provider must be one of: %s", strings.Join(ai.Names(), ", ")), http.StatusBadRequest)
+		return nil, false
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, err := boardDB.GetBoard(req.BoardID, userID)
+	if err != nil {
+		writeBoardError(w, err, "Failed to load board")
+		return nil, false
+	}
+
+	graph, err := boardgraph.Parse(board.Data)
+	if err != nil {
+		log.Printf("[AIHandler] Failed to read board %s: %v", board.ID, err)
+		http.Error(w, "Board data could not be read", http.StatusUnprocessableEntity)
+		return nil, false
+	}
+	node := graph.Node(req.NodeID)
+	if node == nil {
+		http.Error(w, "Node not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	apiKey, selectedModel, err := h.providerKey(userID, provider)
+	if err != nil {
+		writeProviderKeyError(w, req.Provider, userID, err)
+		return nil, false
+	}
+
+	model := req.Model
+	if model == "" {
+		model = selectedModel
+	}
+	if model == "" {
+		http.Error(w, "No model selected for this provider", http.StatusBadRequest)
+		return nil, false
+	}
+
+	return &brainstormCall{
+		userID:   userID,
+		provider: provider,
+		apiKey:   apiKey,
+		request: ai.Request{
+			Model:       model,
+			System:      ai.BrainstormSystemPrompt,
+			Messages:    ai.BrainstormMessages(board.Name, graph, node, req.Message),
+			Temperature: ai.DefaultTemperature,
+		},
+	}, true
+}
+
+// ListModels handles GET /api/ai/models?provider= and lists the models the
+// user's saved key can use with the provider
+func (h *AIHandler) ListModels(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[AIHandler] ListModels - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[AIHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	providerName := r.URL.Query().Get("provider")
+	provider, err := ai.Lookup(providerName)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Provider must be one of: %s", strings.Join(ai.Names(), ", ")), http.StatusBadRequest)
+		return
+	}
+
+	apiKey, _, err := h.providerKey(userID, provider)
+	if err != nil {
+		writeProviderKeyError(w, providerName, userID, err)
+		return
+	}
+
+	started := time.Now()
+	modelIDs, err := provider.ListModels(r.Context(), apiKey)
+	if err != nil {
+		h.recordUsage(userID, providerName, "", models.AIOperationListModels, started, ai.Usage{}, failedOutcome(r))
+		writeAIError(w, providerName, err)
+		return
+	}
+	h.recordUsage(userID, providerName, "", models.AIOperationListModels, started, ai.Usage{}, models.AIOutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"provider": providerName,
+		"models":   modelIDs,
+	})
+}
+
+// providerKey returns the decrypted API key and the selected model the user
+// saved for provider. The key is empty for a provider that needs none when
+// the user has not saved one.
+func (h *AIHandler) providerKey(userID string, provider ai.Provider) (string, string, error) {
+	settings, err := h.DB.GetUserSettings(userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	var aiSettings map[string]ProviderSettings
+	if err := settings.AISettings.Unmarshal(&aiSettings); err != nil {
+		return "", "", err
+	}
+
+	saved := aiSettings[provider.Name()]
+	if saved.Key == "" {
+		if !provider.RequiresKey() {
+			return "", saved.SelectedModel, nil
+		}
+		return "", "", errNoProviderKey
+	}
+
+	apiKey, err := encryption.Decrypt(saved.Key)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Keys saved before SaveKeys rejected them may still carry a Bearer prefix
+	apiKey = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(apiKey), "Bearer "))
+	if apiKey == "" && provider.RequiresKey() {
+		return "", "", errNoProviderKey
+	}
+
+	return apiKey, saved.SelectedModel, nil
+}
+
+// recordCall records a brainstorm request to the provider. completion is
+// what the provider returned, which for a stream that failed part way holds
+// what was received before the failure; tokens it does not report are estimated.
+func (h *AIHandler) recordCall(call *brainstormCall, operation string, started time.Time, completion *ai.Completion, outcome string) {
+	usage := ai.MeteredUsage(call.request, completion)
+	h.recordUsage(call.userID, call.provider.Name(), call.request.Model, operation, started, usage, outcome)
+}
+
+// recordUsage stores a provider request in ai_usage. Failures are only
+// logged, so metering never fails the request itself.
+func (h *AIHandler) recordUsage(userID, provider, model, operation string, started time.Time, usage ai.Usage, outcome string) {
+	err := h.DB.RecordAIUsage(&models.AIUsage{
+		UserID:           userID,
+		Provider:         provider,
+		Model:            model,
+		Operation:        operation,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		LatencyMs:        time.Since(started).Milliseconds(),
+		Outcome:          outcome,
+	})
+	if err != nil {
+		log.Printf("[AIHandler] Error recording %s usage for user %s: %v", provider, userID, err)
+	}
+}
+
+// failedOutcome returns the outcome recorded for a failed provider request,
+// telling requests the client gave up on apart from provider errors
+func failedOutcome(r *http.Request) string {
+	if r.Context().Err() != nil {
+		return models.AIOutcomeCancelled
+	}
+	return models.AIOutcomeError
+}
+
+// writeProviderKeyError responds to a saved key that is missing or cannot be decrypted
+func writeProviderKeyError(w http.ResponseWriter, provider, userID string, err error) {
+	if errors.Is(err, errNoProviderKey) {
+		http.Error(w, "No API key saved for this provider. Please add one in Settings.", http.StatusBadRequest)
+		return
+	}
+	log.Printf("[AIHandler] Error loading %s key for user %s: %v", provider, userID, err)
+	http.Error(w, "Failed to read your API key. Please re-enter it in Settings.", http.StatusBadRequest)
+}
+
+// writeAIError maps a failed provider call to a response
+func writeAIError(w http.ResponseWriter, provider string, err error) {
+	log.Printf("[AIHandler] %s request failed: %v", provider, err)
+	status, message := aiErrorResponse(err)
+	http.Error(w, message, status)
+}
+
+// aiErrorResponse returns the status and message sent to the client for a
+// failed provider call. Provider messages are only logged, since they can
+// echo part of the key.
+func aiErrorResponse(err error) (int, string) {
+	var providerErr *ai.ProviderError
+	switch {
+	case errors.As(err, &providerErr) && providerErr.Unauthorized():
+		return http.StatusBadRequest, "The provider rejected your API key. Please re-enter it in Settings."
+	case errors.As(err, &providerErr) && providerErr.StatusCode == http.StatusTooManyRequests:
+		return http.StatusTooManyRequests, "The provider is rate limiting your API key. Please try again later."
+	case errors.Is(err, ai.ErrEmptyCompletion):
+		return http.StatusBadGateway, "The AI provider returned an empty response. Please try again."
+	default:
+		return http.StatusBadGateway, "The AI provider request failed"
+	}
+}
+
+// writeAIEvent writes one Server-Sent Event with a JSON payload
+func writeAIEvent(w http.ResponseWriter, event string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
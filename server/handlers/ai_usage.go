@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"saas-server/middleware"
+	"time"
+)
+
+// GetAIUsage handles GET /api/user/ai-usage?month=YYYY-MM and returns the
+// user's AI requests and tokens in the month with their plan's limits. The
+// month defaults to the current one (UTC).
+func (h *AIHandler) GetAIUsage(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[AIHandler] GetAIUsage - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[AIHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	month, ok := aiUsageMonth(w, r)
+	if !ok {
+		return
+	}
+
+	usage, err := h.DB.GetAIMonthlyUsage(userID, month)
+	if err != nil {
+		log.Printf("[AIHandler] Error getting AI usage for user %s: %v", userID, err)
+		http.Error(w, "Failed to get AI usage", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
+
+// AdminAIUsageReport handles GET /admin/ai-usage?month=YYYY-MM and
+// aggregates the AI usage of every user by provider, model and user
+func (h *AIHandler) AdminAIUsageReport(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[AIHandler] AdminAIUsageReport - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	month, ok := aiUsageMonth(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := h.DB.GetAIUsageReport(month)
+	if err != nil {
+		log.Printf("[AIHandler] Error building AI usage report: %v", err)
+		http.Error(w, "Failed to get AI usage report", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// aiUsageMonth reads the optional month query parameter, defaulting to now
+func aiUsageMonth(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	value := r.URL.Query().Get("month")
+	if value == "" {
+		return time.Now(), true
+	}
+
+	month, err := time.Parse("2006-01", value)
+	if err != nil {
+		http.Error(w, "Month must be in YYYY-MM format", http.StatusBadRequest)
+		return time.Time{}, false
+	}
+	return month, true
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"saas-server/database"
+	"saas-server/middleware"
+	"saas-server/models"
+	"saas-server/pkg/email"
+	"saas-server/pkg/validation"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxCommentLength is the longest comment body accepted, in characters
+	maxCommentLength = 5000
+	// mentionExcerptLength is how much of a comment is quoted in mention emails
+	mentionExcerptLength = 300
+)
+
+// ListBoardComments handles requests to list the comment threads of a board,
+// optionally only those on the node given by the nodeId parameter
+func (h *BoardHandler) ListBoardComments(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] ListBoardComments - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	threads, err := boardDB.ListComments(boardID, userID, r.URL.Query().Get("nodeId"))
+	if err != nil {
+		writeCommentError(w, err, "Failed to list comments")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(threads)
+}
+
+// CreateBoardComment handles requests to comment on a node or reply to a
+// thread. Mentioned users are notified by email.
+func (h *BoardHandler) CreateBoardComment(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] CreateBoardComment - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+
+	var req models.BoardCommentCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.ParentID != "" {
+		if _, err := uuid.Parse(req.ParentID); err != nil {
+			http.Error(w, "Invalid parent comment ID format", http.StatusBadRequest)
+			return
+		}
+	} else if req.NodeID == "" {
+		http.Error(w, "Node ID is required", http.StatusBadRequest)
+		return
+	}
+
+	body, ok := validateCommentBody(w, req.Body)
+	if !ok {
+		return
+	}
+	req.Body = body
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	comment, mentions, err := boardDB.CreateComment(boardID, userID, req)
+	if err != nil {
+		writeCommentError(w, err, "Failed to create comment")
+		return
+	}
+
+	log.Printf("[BoardHandler] Comment %s added to node %s of board %s", comment.ID, comment.NodeID, boardID)
+	h.sendMentionEmails(boardDB, boardID, userID, comment, mentions)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+// UpdateBoardComment handles requests from a comment's author to edit it.
+// Only users who were not mentioned before are notified.
+func (h *BoardHandler) UpdateBoardComment(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] UpdateBoardComment - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Accept POST and PUT requests
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+	commentID, ok := requireUUIDParam(w, r, "commentId", "Comment ID")
+	if !ok {
+		return
+	}
+
+	var req models.BoardCommentUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	body, ok := validateCommentBody(w, req.Body)
+	if !ok {
+		return
+	}
+	req.Body = body
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	comment, mentions, err := boardDB.UpdateComment(boardID, commentID, userID, req)
+	if err != nil {
+		writeCommentError(w, err, "Failed to update comment")
+		return
+	}
+
+	h.sendMentionEmails(boardDB, boardID, userID, comment, mentions)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+// ResolveBoardComment handles requests to resolve or reopen a thread
+func (h *BoardHandler) ResolveBoardComment(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] ResolveBoardComment - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Accept POST and PUT requests
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+	commentID, ok := requireUUIDParam(w, r, "commentId", "Comment ID")
+	if !ok {
+		return
+	}
+
+	var req models.BoardCommentResolveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	comment, err := boardDB.ResolveComment(boardID, commentID, userID, req.Resolved)
+	if err != nil {
+		writeCommentError(w, err, "Failed to resolve comment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+// DeleteBoardComment handles requests to delete a comment. Deleting a thread
+// also deletes its replies.
+func (h *BoardHandler) DeleteBoardComment(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] DeleteBoardComment - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Accept POST and DELETE requests
+	if r.Method != http.MethodDelete && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+	commentID, ok := requireUUIDParam(w, r, "commentId", "Comment ID")
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	if err := boardDB.DeleteComment(boardID, commentID, userID); err != nil {
+		writeCommentError(w, err, "Failed to delete comment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Comment deleted successfully",
+	})
+}
+
+// validateCommentBody trims a comment body and checks that it is neither
+// empty nor too long, writing a 400 response if it is
+func validateCommentBody(w http.ResponseWriter, body string) (string, bool) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		http.Error(w, "Comment body is required", http.StatusBadRequest)
+		return "", false
+	}
+	if utf8.RuneCountInString(body) > maxCommentLength {
+		http.Error(w, fmt.Sprintf("Comment body must be at most %d characters", maxCommentLength), http.StatusBadRequest)
+		return "", false
+	}
+	return body, true
+}
+
+// writeCommentError maps comment errors to responses and falls back to writeBoardError
+func writeCommentError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, database.ErrCommentNotFound):
+		http.Error(w, "Comment not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrNodeNotFound):
+		http.Error(w, "Node not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrCommentNotThread):
+		http.Error(w, "Only top-level comments can be resolved", http.StatusBadRequest)
+	case errors.Is(err, database.ErrInvalidMention):
+		http.Error(w, "Mentions must be users with access to the board", http.StatusBadRequest)
+	default:
+		writeBoardError(w, err, fallback)
+	}
+}
+
+// sendMentionEmails lets users know they were mentioned in a comment.
+// Failures are logged and do not affect the comment itself.
+func (h *BoardHandler) sendMentionEmails(boardDB *database.BoardDB, boardID, authorID string, comment *models.BoardComment, mentions []models.BoardCommentMention) {
+	if len(mentions) == 0 {
+		return
+	}
+
+	board, err := boardDB.GetBoard(boardID, authorID)
+	if err != nil {
+		log.Printf("[BoardHandler] Could not load board for mention emails: %v", err)
+		return
+	}
+
+	authorName := "Someone"
+	if comment.AuthorName != "" {
+		authorName = comment.AuthorName
+	}
+
+	excerpt := validation.SanitizeInput(comment.Body, mentionExcerptLength)
+	boardURL := fmt.Sprintf("%s/boards/%s", os.Getenv("FRONTEND_URL"), boardID)
+	go func() {
+		for _, mention := range mentions {
+			if err := email.SendCommentMentionEmail(mention.Email, authorName, board.Name, excerpt, boardURL); err != nil {
+				log.Printf("[BoardHandler] Error sending mention email to %s: %v", mention.Email, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"saas-server/database"
+	"saas-server/middleware"
+	"saas-server/models"
+	"saas-server/pkg/boardcopy"
+	"strings"
+)
+
+// DuplicateBoard handles POST /api/boards/duplicate. The source is either a
+// board the user can read, given by ?id=, or a public board, given by the
+// share link ?token= (with X-Share-Password for protected links). The copy is
+// owned by the user, gets fresh node and edge IDs and records its source in
+// forkedFrom. An optional JSON body can set the new board's name.
+func (h *BoardHandler) DuplicateBoard(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] DuplicateBoard - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// The body is optional; without a name the copy is called "Copy of ..."
+	var req models.BoardDuplicateRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+
+	var source *models.Board
+	if r.URL.Query().Get("token") != "" {
+		board, ok := h.resolveShareLink(w, r)
+		if !ok {
+			return
+		}
+		source = board
+	} else {
+		boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+		if !ok {
+			return
+		}
+		board, err := boardDB.GetBoard(boardID, userID)
+		if err != nil {
+			writeBoardError(w, err, "Failed to duplicate board")
+			return
+		}
+		source = board
+	}
+
+	data, err := boardcopy.Copy(source.Data)
+	if err != nil {
+		log.Printf("[BoardHandler] Failed to copy data of board %s: %v", source.ID, err)
+		http.Error(w, "Board data could not be copied", http.StatusUnprocessableEntity)
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "Copy of " + source.Name
+	}
+
+	board, err := boardDB.CreateBoard(userID, models.BoardCreateRequest{
+		Name:        truncateRunes(name, maxBoardNameLength),
+		Description: source.Description,
+		Data:        &data,
+		ForkedFrom:  &source.ID,
+	})
+	if err != nil {
+		writeBoardError(w, err, "Failed to duplicate board")
+		return
+	}
+
+	log.Printf("[BoardHandler] Board %s duplicated as %s", source.ID, board.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", boardETag(board.Version))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newBoardResponse(board))
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"saas-server/database"
+	"saas-server/middleware"
+	"saas-server/models"
+	"saas-server/pkg/boardpatch"
+
+	"github.com/google/uuid"
+)
+
+// The handlers in this file serve /api/boards/{id}/nodes/{nodeId} and
+// /api/boards/{id}/edges/{edgeId}. Routes are registered per method, so the
+// handlers do not check the method themselves. Writes honour If-Match like
+// PatchBoard, return the new version in the ETag header and announce it to
+// the board's live sessions.
+
+// GetBoardNode handles GET /api/boards/{id}/nodes/{nodeId}
+func (h *BoardHandler) GetBoardNode(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] GetBoardNode - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	userID, boardID, ok := boardElementRequest(w, r)
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, node, err := boardDB.GetNode(boardID, userID, r.PathValue("nodeId"))
+	if err != nil {
+		writeBoardElementError(w, boardDB, boardID, userID, err, "Failed to get node")
+		return
+	}
+
+	writeBoardElement(w, http.StatusOK, board.Version, models.BoardNodeResponse{
+		BoardID: board.ID,
+		Version: board.Version,
+		Node:    node,
+	})
+}
+
+// CreateBoardNode handles POST /api/boards/{id}/nodes. The body holds the
+// node and optionally the ID of a parent node to connect it to.
+func (h *BoardHandler) CreateBoardNode(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] CreateBoardNode - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	userID, boardID, ok := boardElementRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.BoardNodeCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Node) == 0 {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	expectedVersion, ok := elementIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, nodeID, edgeID, err := boardDB.AddNode(boardID, userID, req.Node, req.ParentID, expectedVersion)
+	if err != nil {
+		writeBoardElementError(w, boardDB, boardID, userID, err, "Failed to create node")
+		return
+	}
+	h.publishBoardUpdate(board, userID)
+
+	response := models.BoardNodeResponse{BoardID: board.ID, Version: board.Version}
+	response.Node, _ = boardpatch.Find(board.Data.Nodes, nodeID)
+	if edgeID != "" {
+		response.Edge, _ = boardpatch.Find(board.Data.Edges, edgeID)
+	}
+
+	log.Printf("[BoardHandler] Node %s added to board %s", nodeID, boardID)
+	writeBoardElement(w, http.StatusCreated, board.Version, response)
+}
+
+// UpdateBoardNode handles PATCH /api/boards/{id}/nodes/{nodeId}. The body is
+// a JSON Merge Patch applied to the node.
+func (h *BoardHandler) UpdateBoardNode(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] UpdateBoardNode - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	userID, boardID, ok := boardElementRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var patch json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	expectedVersion, ok := elementIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	nodeID := r.PathValue("nodeId")
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, err := boardDB.UpdateNode(boardID, userID, nodeID, patch, expectedVersion)
+	if err != nil {
+		writeBoardElementError(w, boardDB, boardID, userID, err, "Failed to update node")
+		return
+	}
+	h.publishBoardUpdate(board, userID)
+
+	node, _ := boardpatch.Find(board.Data.Nodes, nodeID)
+	writeBoardElement(w, http.StatusOK, board.Version, models.BoardNodeResponse{
+		BoardID: board.ID,
+		Version: board.Version,
+		Node:    node,
+	})
+}
+
+// DeleteBoardNode handles DELETE /api/boards/{id}/nodes/{nodeId}. Edges
+// connected to the node are deleted with it.
+func (h *BoardHandler) DeleteBoardNode(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] DeleteBoardNode - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	userID, boardID, ok := boardElementRequest(w, r)
+	if !ok {
+		return
+	}
+
+	expectedVersion, ok := elementIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	nodeID := r.PathValue("nodeId")
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, removedEdges, err := boardDB.DeleteNode(boardID, userID, nodeID, expectedVersion)
+	if err != nil {
+		writeBoardElementError(w, boardDB, boardID, userID, err, "Failed to delete node")
+		return
+	}
+	h.publishBoardUpdate(board, userID)
+
+	log.Printf("[BoardHandler] Node %s deleted from board %s with %d edges", nodeID, boardID, len(removedEdges))
+	writeBoardElement(w, http.StatusOK, board.Version, models.BoardElementDeleteResponse{
+		BoardID:      board.ID,
+		Version:      board.Version,
+		RemovedNodes: []string{nodeID},
+		RemovedEdges: removedEdges,
+	})
+}
+
+// GetBoardEdge handles GET /api/boards/{id}/edges/{edgeId}
+func (h *BoardHandler) GetBoardEdge(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] GetBoardEdge - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	userID, boardID, ok := boardElementRequest(w, r)
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, edge, err := boardDB.GetEdge(boardID, userID, r.PathValue("edgeId"))
+	if err != nil {
+		writeBoardElementError(w, boardDB, boardID, userID, err, "Failed to get edge")
+		return
+	}
+
+	writeBoardElement(w, http.StatusOK, board.Version, models.BoardEdgeResponse{
+		BoardID: board.ID,
+		Version: board.Version,
+		Edge:    edge,
+	})
+}
+
+// CreateBoardEdge handles POST /api/boards/{id}/edges. The body is the edge;
+// its source and target nodes must exist.
+func (h *BoardHandler) CreateBoardEdge(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] CreateBoardEdge - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	userID, boardID, ok := boardElementRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var edge json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&edge); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	expectedVersion, ok := elementIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, edgeID, err := boardDB.AddEdge(boardID, userID, edge, expectedVersion)
+	if err != nil {
+		writeBoardElementError(w, boardDB, boardID, userID, err, "Failed to create edge")
+		return
+	}
+	h.publishBoardUpdate(board, userID)
+
+	created, _ := boardpatch.Find(board.Data.Edges, edgeID)
+	log.Printf("[BoardHandler] Edge %s added to board %s", edgeID, boardID)
+	writeBoardElement(w, http.StatusCreated, board.Version, models.BoardEdgeResponse{
+		BoardID: board.ID,
+		Version: board.Version,
+		Edge:    created,
+	})
+}
+
+// UpdateBoardEdge handles PATCH /api/boards/{id}/edges/{edgeId}. The body is
+// a JSON Merge Patch applied to the edge.
+func (h *BoardHandler) UpdateBoardEdge(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] UpdateBoardEdge - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	userID, boardID, ok := boardElementRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var patch json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	expectedVersion, ok := elementIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	edgeID := r.PathValue("edgeId")
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, err := boardDB.UpdateEdge(boardID, userID, edgeID, patch, expectedVersion)
+	if err != nil {
+		writeBoardElementError(w, boardDB, boardID, userID, err, "Failed to update edge")
+		return
+	}
+	h.publishBoardUpdate(board, userID)
+
+	edge, _ := boardpatch.Find(board.Data.Edges, edgeID)
+	writeBoardElement(w, http.StatusOK, board.Version, models.BoardEdgeResponse{
+		BoardID: board.ID,
+		Version: board.Version,
+		Edge:    edge,
+	})
+}
+
+// DeleteBoardEdge handles DELETE /api/boards/{id}/edges/{edgeId}
+func (h *BoardHandler) DeleteBoardEdge(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] DeleteBoardEdge - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	userID, boardID, ok := boardElementRequest(w, r)
+	if !ok {
+		return
+	}
+
+	expectedVersion, ok := elementIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	edgeID := r.PathValue("edgeId")
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, err := boardDB.DeleteEdge(boardID, userID, edgeID, expectedVersion)
+	if err != nil {
+		writeBoardElementError(w, boardDB, boardID, userID, err, "Failed to delete edge")
+		return
+	}
+	h.publishBoardUpdate(board, userID)
+
+	writeBoardElement(w, http.StatusOK, board.Version, models.BoardElementDeleteResponse{
+		BoardID:      board.ID,
+		Version:      board.Version,
+		RemovedNodes: []string{},
+		RemovedEdges: []string{edgeID},
+	})
+}
+
+// boardElementRequest reads the user ID and validates the board ID in the path
+func boardElementRequest(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return "", "", false
+	}
+
+	boardID := r.PathValue("id")
+	if _, err := uuid.Parse(boardID); err != nil {
+		log.Printf("[BoardHandler] Invalid board ID format: %s", boardID)
+		http.Error(w, "Invalid board ID format", http.StatusBadRequest)
+		return "", "", false
+	}
+
+	return userID, boardID, true
+}
+
+// elementIfMatch reads the expected board version from If-Match
+func elementIfMatch(w http.ResponseWriter, r *http.Request) (int, bool) {
+	expectedVersion, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		log.Printf("[BoardHandler] Invalid If-Match header: %q", r.Header.Get("If-Match"))
+		http.Error(w, "Invalid If-Match header", http.StatusBadRequest)
+		return 0, false
+	}
+	return expectedVersion, true
+}
+
+// writeBoardElement writes a node or edge response with the board's ETag
+func writeBoardElement(w http.ResponseWriter, status, version int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", boardETag(version))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeBoardElementError maps node and edge errors to HTTP responses
+func writeBoardElementError(w http.ResponseWriter, boardDB *database.BoardDB, boardID, userID string, err error, fallback string) {
+	switch {
+	case err == database.ErrBoardVersionConflict:
+		writeVersionConflict(w, boardDB, boardID, userID)
+	case err == database.ErrNodeNotFound:
+		http.Error(w, "Node not found", http.StatusNotFound)
+	case err == database.ErrEdgeNotFound:
+		http.Error(w, "Edge not found", http.StatusNotFound)
+	case errors.Is(err, boardpatch.ErrInvalidPatch):
+		log.Printf("[BoardHandler] Rejected change to board %s: %v", boardID, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeBoardError(w, err, fallback)
+	}
+}
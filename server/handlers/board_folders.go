@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"saas-server/database"
+	"saas-server/middleware"
+	"saas-server/models"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// maxFolderNameLength matches the board_folders.name column
+const maxFolderNameLength = 100
+
+// ListBoardFolders handles requests to list the user's board folders
+func (h *BoardHandler) ListBoardFolders(w http.ResponseWriter, r *http.Request) {
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	folders, err := boardDB.ListFolders(userID)
+	if err != nil {
+		log.Printf("[BoardHandler] Failed to list folders: %v", err)
+		http.Error(w, "Failed to list folders", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(folders)
+}
+
+// CreateBoardFolder handles requests to create a folder
+func (h *BoardHandler) CreateBoardFolder(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] CreateBoardFolder - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	req, ok := decodeFolderRequest(w, r)
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	folder, err := boardDB.CreateFolder(userID, req.Name, req.ParentID)
+	if err != nil {
+		writeFolderError(w, err, "Failed to create folder")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(folder)
+}
+
+// UpdateBoardFolder handles requests to rename a folder or move it under another folder
+func (h *BoardHandler) UpdateBoardFolder(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] UpdateBoardFolder - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Accept POST and PUT requests
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	folderID, ok := requireUUIDParam(w, r, "id", "Folder ID")
+	if !ok {
+		return
+	}
+
+	req, ok := decodeFolderRequest(w, r)
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	folder, err := boardDB.UpdateFolder(userID, folderID, req.Name, req.ParentID)
+	if err != nil {
+		writeFolderError(w, err, "Failed to update folder")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(folder)
+}
+
+// DeleteBoardFolder handles requests to delete a folder and its subfolders.
+// Boards inside are moved to the top level rather than deleted.
+func (h *BoardHandler) DeleteBoardFolder(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] DeleteBoardFolder - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Accept POST and DELETE requests
+	if r.Method != http.MethodDelete && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	folderID, ok := requireUUIDParam(w, r, "id", "Folder ID")
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	if err := boardDB.DeleteFolder(userID, folderID); err != nil {
+		writeFolderError(w, err, "Failed to delete folder")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Folder deleted successfully",
+	})
+}
+
+// MoveBoardToFolder handles requests from a board owner to file a board in a folder
+func (h *BoardHandler) MoveBoardToFolder(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] MoveBoardToFolder - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Accept POST and PUT requests
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+
+	var req models.BoardMoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.FolderID != nil {
+		if _, err := uuid.Parse(*req.FolderID); err != nil {
+			http.Error(w, "Invalid folder ID format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	if err := boardDB.MoveBoard(boardID, userID, req.FolderID); err != nil {
+		if err == database.ErrFolderNotFound {
+			writeFolderError(w, err, "Failed to move board")
+			return
+		}
+		writeBoardError(w, err, "Failed to move board")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"folderId": req.FolderID,
+	})
+}
+
+// ListBoardTags handles requests to list the tags used on the user's boards
+func (h *BoardHandler) ListBoardTags(w http.ResponseWriter, r *http.Request) {
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	tags, err := boardDB.ListTags(userID)
+	if err != nil {
+		log.Printf("[BoardHandler] Failed to list tags: %v", err)
+		http.Error(w, "Failed to list tags", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// SetBoardTags handles requests to replace the tags of a board
+func (h *BoardHandler) SetBoardTags(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] SetBoardTags - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Accept POST and PUT requests
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+
+	var req models.BoardTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tags, err := database.NormalizeTags(req.Tags)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	tags, err = boardDB.SetBoardTags(boardID, userID, tags)
+	if err != nil {
+		writeBoardError(w, err, "Failed to update tags")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":   boardID,
+		"tags": tags,
+	})
+}
+
+// decodeFolderRequest reads and validates a folder payload
+func decodeFolderRequest(w http.ResponseWriter, r *http.Request) (models.BoardFolderRequest, bool) {
+	var req models.BoardFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return req, false
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "Folder name is required", http.StatusBadRequest)
+		return req, false
+	}
+	if utf8.RuneCountInString(req.Name) > maxFolderNameLength || strings.Contains(req.Name, "/") {
+		http.Error(w, "Folder name must be at most 100 characters and cannot contain /", http.StatusBadRequest)
+		return req, false
+	}
+
+	if req.ParentID != nil {
+		if _, err := uuid.Parse(*req.ParentID); err != nil {
+			http.Error(w, "Invalid parent folder ID format", http.StatusBadRequest)
+			return req, false
+		}
+	}
+
+	return req, true
+}
+
+// writeFolderError maps folder errors to HTTP responses
+func writeFolderError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case database.ErrFolderNotFound:
+		http.Error(w, "Folder not found", http.StatusNotFound)
+	case database.ErrFolderNameTaken:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case database.ErrFolderCycle:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("[BoardHandler] %s: %v", fallback, err)
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
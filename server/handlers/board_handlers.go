@@ -2,11 +2,19 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"saas-server/database"
 	"saas-server/middleware"
 	"saas-server/models"
+	"saas-server/pkg/boardcopy"
+	"saas-server/pkg/boardpatch"
+	"saas-server/pkg/boardvalidate"
+	"saas-server/pkg/realtime"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -14,11 +22,24 @@ import (
 // BoardHandler contains all the handlers for board operations
 type BoardHandler struct {
 	DB *database.DB
+	// Hub tells the sessions connected to a board about changes saved here
+	Hub realtime.Hub
 }
 
 // NewBoardHandler creates a new BoardHandler instance
-func NewBoardHandler(db *database.DB) *BoardHandler {
-	return &BoardHandler{DB: db}
+func NewBoardHandler(db *database.DB, hub realtime.Hub) *BoardHandler {
+	return &BoardHandler{DB: db, Hub: hub}
+}
+
+// publishBoardUpdate tells the sessions connected to a board that userID
+// saved a new version of it
+func (h *BoardHandler) publishBoardUpdate(board *models.Board, userID string) {
+	h.Hub.Publish(realtime.Event{
+		Type:    realtime.EventUpdate,
+		BoardID: board.ID,
+		UserID:  userID,
+		Version: board.Version,
+	})
 }
 
 // CreateBoard handles requests to create a new board
@@ -101,29 +122,50 @@ func (h *BoardHandler) CreateBoard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create board in database
 	boardDB := database.NewBoardDB(h.DB.DB)
+
+	// Seed the board with a copy of the template's content, with fresh IDs
+	if req.TemplateID != "" {
+		if _, err := uuid.Parse(req.TemplateID); err != nil {
+			log.Printf("[BoardHandler] Invalid template ID format: %s", req.TemplateID)
+			http.Error(w, "Invalid template ID format", http.StatusBadRequest)
+			return
+		}
+
+		template, err := boardDB.GetTemplate(req.TemplateID, userID)
+		if err != nil {
+			if errors.Is(err, database.ErrTemplateNotFound) {
+				http.Error(w, "Template not found", http.StatusNotFound)
+				return
+			}
+			log.Printf("[BoardHandler] Failed to load template %s: %v", req.TemplateID, err)
+			http.Error(w, "Failed to create board", http.StatusInternalServerError)
+			return
+		}
+
+		data, err := boardcopy.Copy(*template.Data)
+		if err != nil {
+			log.Printf("[BoardHandler] Failed to copy data of template %s: %v", template.ID, err)
+			http.Error(w, "Template data could not be copied", http.StatusUnprocessableEntity)
+			return
+		}
+		req.Data = &data
+	}
+
+	// Create board in database
 	board, err := boardDB.CreateBoard(userID, req)
 	if err != nil {
-		log.Printf("[BoardHandler] Failed to create board: %v", err)
-		http.Error(w, "Failed to create board", http.StatusInternalServerError)
+		writeBoardError(w, err, "Failed to create board")
 		return
 	}
 
 	// Prepare response
-	response := models.BoardResponse{
-		ID:          board.ID,
-		Name:        board.Name,
-		Description: board.Description,
-		Nodes:       []interface{}{},
-		Edges:       []interface{}{},
-		CreatedAt:   board.CreatedAt,
-		UpdatedAt:   board.UpdatedAt,
-	}
+	response := newBoardResponse(board)
 
 	// Return created board
 	log.Printf("[BoardHandler] Board created successfully: id=%s, name=%s", board.ID, board.Name)
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", boardETag(board.Version))
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
 }
@@ -180,36 +222,13 @@ func (h *BoardHandler) GetBoard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse board data into interfaces
-	var nodes []interface{}
-	var edges []interface{}
-
-	// Unmarshal nodes
-	if err := json.Unmarshal([]byte(`[`+joinRawMessages(board.Data.Nodes, ",")+`]`), &nodes); err != nil {
-		log.Printf("[BoardHandler] Error unmarshaling nodes: %v", err)
-		nodes = []interface{}{}
-	}
-
-	// Unmarshal edges
-	if err := json.Unmarshal([]byte(`[`+joinRawMessages(board.Data.Edges, ",")+`]`), &edges); err != nil {
-		log.Printf("[BoardHandler] Error unmarshaling edges: %v", err)
-		edges = []interface{}{}
-	}
-
 	// Prepare response
-	response := models.BoardResponse{
-		ID:          board.ID,
-		Name:        board.Name,
-		Description: board.Description,
-		Nodes:       nodes,
-		Edges:       edges,
-		CreatedAt:   board.CreatedAt,
-		UpdatedAt:   board.UpdatedAt,
-	}
+	response := newBoardResponse(board)
 
 	// Return board
 	log.Printf("[BoardHandler] Board fetched successfully: id=%s, name=%s", board.ID, board.Name)
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", boardETag(board.Version))
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -268,49 +287,109 @@ func (h *BoardHandler) UpdateBoard(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[BoardHandler] Board update request for boardID=%s: name=%q, description=%q",
 		boardID, req.Name, req.Description)
 
+	// Read the version the client based its changes on, if any
+	expectedVersion, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		log.Printf("[BoardHandler] Invalid If-Match header: %q", r.Header.Get("If-Match"))
+		http.Error(w, "Invalid If-Match header", http.StatusBadRequest)
+		return
+	}
+
 	// Update board in database
 	boardDB := database.NewBoardDB(h.DB.DB)
-	board, err := boardDB.UpdateBoard(boardID, userID, req)
+	board, err := boardDB.UpdateBoard(boardID, userID, req, expectedVersion)
 	if err != nil {
-		log.Printf("[BoardHandler] Failed to update board: %v", err)
-		http.Error(w, "Failed to update board", http.StatusInternalServerError)
+		if err == database.ErrBoardVersionConflict {
+			log.Printf("[BoardHandler] Version conflict updating board %s: If-Match=%d", boardID, expectedVersion)
+			writeVersionConflict(w, boardDB, boardID, userID)
+			return
+		}
+		writeBoardError(w, err, "Failed to update board")
 		return
 	}
+	h.publishBoardUpdate(board, userID)
 
-	// Parse board data into interfaces
-	var nodes []interface{}
-	var edges []interface{}
+	// Prepare response
+	response := newBoardResponse(board)
+
+	// Return updated board
+	log.Printf("[BoardHandler] Board updated successfully: id=%s, name=%s", board.ID, board.Name)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", boardETag(board.Version))
+	json.NewEncoder(w).Encode(response)
+}
+
+// PatchBoard handles requests to add, update and remove individual nodes and
+// edges without resending the whole board
+func (h *BoardHandler) PatchBoard(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] PatchBoard - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
 
-	// Unmarshal nodes
-	if err := json.Unmarshal([]byte(`[`+joinRawMessages(board.Data.Nodes, ",")+`]`), &nodes); err != nil {
-		log.Printf("[BoardHandler] Error unmarshaling nodes: %v", err)
-		nodes = []interface{}{}
+	// Accept POST and PATCH requests
+	if r.Method != http.MethodPatch && r.Method != http.MethodPost {
+		log.Printf("[BoardHandler] Method not allowed: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get user ID from context using the middleware helper
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
 	}
 
-	// Unmarshal edges
-	if err := json.Unmarshal([]byte(`[`+joinRawMessages(board.Data.Edges, ",")+`]`), &edges); err != nil {
-		log.Printf("[BoardHandler] Error unmarshaling edges: %v", err)
-		edges = []interface{}{}
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
 	}
 
-	// Prepare response
-	response := models.BoardResponse{
-		ID:          board.ID,
-		Name:        board.Name,
-		Description: board.Description,
-		Nodes:       nodes,
-		Edges:       edges,
-		CreatedAt:   board.CreatedAt,
-		UpdatedAt:   board.UpdatedAt,
+	// Parse request body
+	var req models.BoardPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[BoardHandler] Error decoding request body: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
 	}
 
-	// Return updated board
-	log.Printf("[BoardHandler] Board updated successfully: id=%s, name=%s", board.ID, board.Name)
+	expectedVersion, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		log.Printf("[BoardHandler] Invalid If-Match header: %q", r.Header.Get("If-Match"))
+		http.Error(w, "Invalid If-Match header", http.StatusBadRequest)
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, err := boardDB.PatchBoard(boardID, userID, req, expectedVersion)
+	if err != nil {
+		switch {
+		case err == database.ErrBoardVersionConflict:
+			log.Printf("[BoardHandler] Version conflict patching board %s: If-Match=%d", boardID, expectedVersion)
+			writeVersionConflict(w, boardDB, boardID, userID)
+		case errors.Is(err, boardpatch.ErrInvalidPatch):
+			log.Printf("[BoardHandler] Rejected patch for board %s: %v", boardID, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeBoardError(w, err, "Failed to patch board")
+		}
+		return
+	}
+	h.publishBoardUpdate(board, userID)
+
+	log.Printf("[BoardHandler] Board patched successfully: id=%s, version=%d", board.ID, board.Version)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	w.Header().Set("ETag", boardETag(board.Version))
+	json.NewEncoder(w).Encode(models.BoardPatchResponse{
+		ID:        board.ID,
+		Version:   board.Version,
+		NodeCount: len(board.Data.Nodes),
+		EdgeCount: len(board.Data.Edges),
+		UpdatedAt: board.UpdatedAt,
+	})
 }
 
-// DeleteBoard handles requests to delete a board
+// DeleteBoard handles requests to move a board to the trash
 func (h *BoardHandler) DeleteBoard(w http.ResponseWriter, r *http.Request) {
 	// Log request details
 	log.Printf("[BoardHandler] DeleteBoard - Method: %s, Path: %s, Query: %s",
@@ -356,21 +435,23 @@ func (h *BoardHandler) DeleteBoard(w http.ResponseWriter, r *http.Request) {
 	// Delete board from database
 	boardDB := database.NewBoardDB(h.DB.DB)
 	if err := boardDB.DeleteBoard(boardID, userID); err != nil {
-		log.Printf("[BoardHandler] Board not found or access denied: %v", err)
-		http.Error(w, "Board not found or access denied", http.StatusNotFound)
+		log.Printf("[BoardHandler] Failed to delete board: %v", err)
+		writeBoardError(w, err, "Failed to delete board")
 		return
 	}
 
 	// Return success response
-	log.Printf("[BoardHandler] Board deleted successfully: id=%s", boardID)
+	log.Printf("[BoardHandler] Board moved to trash: id=%s", boardID)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"message": "Board deleted successfully",
+		"message": "Board moved to trash",
 	})
 }
 
-// ListBoards handles requests to list all boards for a user
+// ListBoards handles requests to list the boards of a user. The list can be
+// filtered by folder, tag and date, sorted, and paged with the cursor from the
+// Link header of the previous page.
 func (h *BoardHandler) ListBoards(w http.ResponseWriter, r *http.Request) {
 	// Log request details
 	log.Printf("[BoardHandler] ListBoards - Method: %s, Path: %s", r.Method, r.URL.Path)
@@ -397,21 +478,140 @@ func (h *BoardHandler) ListBoards(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	opts, err := parseBoardListOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Get boards from database
 	boardDB := database.NewBoardDB(h.DB.DB)
-	boards, err := boardDB.ListBoards(userID)
+	boards, nextCursor, err := boardDB.ListBoards(userID, opts)
 	if err != nil {
+		if err == database.ErrInvalidCursor {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
 		log.Printf("[BoardHandler] Failed to list boards: %v", err)
 		http.Error(w, "Failed to list boards", http.StatusInternalServerError)
 		return
 	}
 
+	// Point to the next page the same way GitHub-style APIs do
+	if nextCursor != "" {
+		next := *r.URL
+		query := next.Query()
+		query.Set("cursor", nextCursor)
+		next.RawQuery = query.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+	}
+
 	// Return boards
 	log.Printf("[BoardHandler] Boards fetched successfully: count=%d", len(boards))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(boards)
 }
 
+// writeVersionConflict responds with 412 Precondition Failed and the current
+// server copy of the board so the client can merge or reload
+func writeVersionConflict(w http.ResponseWriter, boardDB *database.BoardDB, boardID, userID string) {
+	current, err := boardDB.GetBoard(boardID, userID)
+	if err != nil {
+		log.Printf("[BoardHandler] Failed to load current board after conflict: %v", err)
+		http.Error(w, "Board has been modified", http.StatusPreconditionFailed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", boardETag(current.Version))
+	w.WriteHeader(http.StatusPreconditionFailed)
+	json.NewEncoder(w).Encode(models.BoardConflictResponse{
+		Error:   "version_conflict",
+		Message: "The board has been modified since it was last fetched",
+		Board:   newBoardResponse(current),
+	})
+}
+
+// writeBoardError maps board access errors to HTTP responses, falling back
+// to a 500 with the given message for anything unexpected
+func writeBoardError(w http.ResponseWriter, err error, fallback string) {
+	var validationErr *boardvalidate.Error
+	var quotaErr *database.QuotaError
+	switch {
+	case errors.As(err, &validationErr):
+		writeValidationError(w, validationErr)
+	case errors.As(err, &quotaErr):
+		writeQuotaError(w, quotaErr)
+	case errors.Is(err, database.ErrBoardNotFound):
+		http.Error(w, "Board not found or access denied", http.StatusNotFound)
+	case errors.Is(err, database.ErrBoardPermissionDenied):
+		http.Error(w, "Insufficient permissions for this board", http.StatusForbidden)
+	default:
+		log.Printf("[BoardHandler] %s: %v", fallback, err)
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+// writeValidationError responds with the field-level errors found in board data
+func writeValidationError(w http.ResponseWriter, err *boardvalidate.Error) {
+	log.Printf("[BoardHandler] Rejected board data: %v", err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(models.BoardValidationErrorResponse{
+		Error:   "invalid_board_data",
+		Message: "The board contains invalid nodes or edges",
+		Fields:  err.Fields,
+	})
+}
+
+// writeQuotaError responds with the plan limit a write would exceed. Running
+// out of boards calls for an upgrade (402); an oversized board is rejected
+// as too large (413).
+func writeQuotaError(w http.ResponseWriter, err *database.QuotaError) {
+	log.Printf("[BoardHandler] Quota exceeded: %v", err)
+
+	status := http.StatusRequestEntityTooLarge
+	message := "This board is larger than your plan allows"
+	switch err.Quota {
+	case database.QuotaBoards:
+		status = http.StatusPaymentRequired
+		message = "You have reached the maximum number of boards for your plan"
+	case database.QuotaStorage:
+		message = "Your boards use more storage than your plan allows"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.BoardQuotaErrorResponse{
+		Error:     "quota_exceeded",
+		Message:   message,
+		Quota:     err.Quota,
+		Limit:     err.Limit,
+		Requested: err.Requested,
+	})
+}
+
+// boardETag formats a board version as a strong ETag
+func boardETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch extracts the expected board version from an If-Match header.
+// An empty header or "*" returns 0, meaning no version check is performed.
+func parseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	header = strings.TrimPrefix(header, "W/")
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid If-Match value: %q", header)
+	}
+	return version, nil
+}
+
 // Helper function to join RawMessage slices
 func joinRawMessages(msgs []json.RawMessage, sep string) string {
 	if len(msgs) == 0 {
@@ -427,3 +627,49 @@ func joinRawMessages(msgs []json.RawMessage, sep string) string {
 	}
 	return result
 }
+
+// decodeRawMessages converts stored node or edge messages into generic values
+// for a response, falling back to an empty list if they cannot be parsed
+func decodeRawMessages(msgs []json.RawMessage) []interface{} {
+	var values []interface{}
+	if err := json.Unmarshal([]byte(`[`+joinRawMessages(msgs, ",")+`]`), &values); err != nil {
+		log.Printf("[BoardHandler] Error unmarshaling board data: %v", err)
+		return []interface{}{}
+	}
+	return values
+}
+
+// newBoardResponse builds the response payload for a board
+func newBoardResponse(board *models.Board) models.BoardResponse {
+	return models.BoardResponse{
+		ID:          board.ID,
+		Name:        board.Name,
+		Description: board.Description,
+		Nodes:       decodeRawMessages(board.Data.Nodes),
+		Edges:       decodeRawMessages(board.Data.Edges),
+		Version:     board.Version,
+		Role:        board.Role,
+		ForkedFrom:  board.ForkedFrom,
+		CreatedAt:   board.CreatedAt,
+		UpdatedAt:   board.UpdatedAt,
+	}
+}
+
+// requireUUIDParam reads a UUID query parameter, writing a 400 response and
+// returning false if it is missing or malformed
+func requireUUIDParam(w http.ResponseWriter, r *http.Request, param, label string) (string, bool) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		log.Printf("[BoardHandler] %s is required", label)
+		http.Error(w, label+" is required", http.StatusBadRequest)
+		return "", false
+	}
+
+	if _, err := uuid.Parse(value); err != nil {
+		log.Printf("[BoardHandler] Invalid %s format: %s", label, value)
+		http.Error(w, "Invalid "+label+" format", http.StatusBadRequest)
+		return "", false
+	}
+
+	return value, true
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"saas-server/database"
+	"saas-server/middleware"
+	"saas-server/models"
+	"saas-server/pkg/boardimport"
+	"strings"
+)
+
+const (
+	// maxImportSize caps the size of an uploaded import file, including multipart overhead
+	maxImportSize = 5 << 20
+	// maxBoardNameLength matches the boards.name column
+	maxBoardNameLength = 255
+)
+
+// ImportBoard handles POST /api/boards/import. It expects a multipart form
+// with a "file" field holding an OPML, Markdown or FreeMind file, and optional
+// "name" and "format" fields. The format is guessed from the file name when
+// not given, and the board name falls back to the title found in the file.
+func (h *BoardHandler) ImportBoard(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] ImportBoard - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "File is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	format := strings.ToLower(strings.TrimSpace(r.FormValue("format")))
+	if format == "" {
+		format = boardimport.DetectFormat(header.Filename)
+	}
+	if format == "" {
+		http.Error(w, "Could not detect file format; set format to opml, markdown or mm", http.StatusBadRequest)
+		return
+	}
+
+	outline, err := boardimport.Parse(format, file)
+	if err != nil {
+		switch {
+		case err == boardimport.ErrUnsupportedFormat:
+			http.Error(w, "Format must be one of: opml, markdown, mm", http.StatusBadRequest)
+		case err == boardimport.ErrTooManyItems:
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		case err == boardimport.ErrEmptyOutline, errors.Is(err, boardimport.ErrInvalidFile):
+			log.Printf("[BoardHandler] Rejected import of %q: %v", header.Filename, err)
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			log.Printf("[BoardHandler] Failed to parse import: %v", err)
+			http.Error(w, "Failed to import board", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	data, err := boardimport.Build(outline)
+	if err != nil {
+		log.Printf("[BoardHandler] Failed to build imported board: %v", err)
+		http.Error(w, "Failed to import board", http.StatusInternalServerError)
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		name = outline.Title
+	}
+	if name == "" {
+		name = strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename))
+	}
+	if name == "" {
+		name = "Imported board"
+	}
+
+	req := models.BoardCreateRequest{
+		Name:        truncateRunes(name, maxBoardNameLength),
+		Description: outline.Description,
+		Data:        &data,
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, err := boardDB.CreateBoard(userID, req)
+	if err != nil {
+		writeBoardError(w, err, "Failed to import board")
+		return
+	}
+
+	log.Printf("[BoardHandler] Board imported from %s: id=%s, nodes=%d", format, board.ID, len(board.Data.Nodes))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", boardETag(board.Version))
+	w.Header().Set("Location", fmt.Sprintf("/api/boards/get?id=%s", board.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newBoardResponse(board))
+}
+
+// truncateRunes shortens s to at most n characters without splitting a character
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"saas-server/database"
+	"saas-server/middleware"
+	"saas-server/models"
+	"saas-server/pkg/boardgraph"
+	"saas-server/pkg/boardlayout"
+	"strings"
+)
+
+// LayoutBoard handles POST /api/boards/layout?id=&algorithm=, rewriting the
+// positions of a board's nodes with one of the boardlayout algorithms
+// ("tree", "radial" or "layered"). With dryRun=true the positions are
+// returned without saving, so the client can preview them; otherwise the
+// change is saved like any other edit and honours If-Match.
+func (h *BoardHandler) LayoutBoard(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] LayoutBoard - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+
+	algorithm, err := boardlayout.Lookup(r.URL.Query().Get("algorithm"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Algorithm must be one of: %s", strings.Join(boardlayout.Names(), ", ")),
+			http.StatusBadRequest)
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+	boardDB := database.NewBoardDB(h.DB.DB)
+
+	var board *models.Board
+	var positions map[string]boardlayout.Position
+	if dryRun {
+		board, err = boardDB.GetBoard(boardID, userID)
+		if err != nil {
+			writeBoardError(w, err, "Failed to lay out board")
+			return
+		}
+
+		graph, err := boardgraph.Parse(board.Data)
+		if err != nil {
+			log.Printf("[BoardHandler] Failed to read board %s for layout: %v", boardID, err)
+			http.Error(w, "Board data could not be read", http.StatusUnprocessableEntity)
+			return
+		}
+		positions = algorithm.Layout(graph)
+	} else {
+		expectedVersion, err := parseIfMatch(r.Header.Get("If-Match"))
+		if err != nil {
+			log.Printf("[BoardHandler] Invalid If-Match header: %q", r.Header.Get("If-Match"))
+			http.Error(w, "Invalid If-Match header", http.StatusBadRequest)
+			return
+		}
+
+		board, positions, err = boardDB.LayoutBoard(boardID, userID, algorithm, expectedVersion)
+		if err != nil {
+			if err == database.ErrBoardVersionConflict {
+				writeVersionConflict(w, boardDB, boardID, userID)
+				return
+			}
+			writeBoardError(w, err, "Failed to lay out board")
+			return
+		}
+		log.Printf("[BoardHandler] Board %s laid out with %s: version=%d", boardID, algorithm.Name(), board.Version)
+		h.publishBoardUpdate(board, userID)
+	}
+
+	response := models.BoardLayoutResponse{
+		BoardID:   board.ID,
+		Version:   board.Version,
+		Algorithm: algorithm.Name(),
+		DryRun:    dryRun,
+		Positions: make(map[string]models.BoardNodePosition, len(positions)),
+	}
+	for id, position := range positions {
+		response.Positions[id] = models.BoardNodePosition{X: position.X, Y: position.Y}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", boardETag(board.Version))
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"saas-server/database"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxBoardListLimit caps the page size of the board list. Without a limit
+// every board is returned on one page, as clients that do not follow the
+// cursor expect.
+const maxBoardListLimit = 100
+
+// parseBoardListOptions reads the query parameters of the board list:
+// folder (an ID, or "root" for boards not in a folder), subfolders, tag
+// (repeatable), dateField, from, to, sort, order, limit and cursor
+func parseBoardListOptions(r *http.Request) (database.BoardListOptions, error) {
+	query := r.URL.Query()
+	opts := database.BoardListOptions{
+		Cursor: query.Get("cursor"),
+	}
+
+	switch folder := query.Get("folder"); folder {
+	case "":
+	case "root":
+		opts.TopLevel = true
+	default:
+		if _, err := uuid.Parse(folder); err != nil {
+			return opts, errors.New("Invalid folder ID format")
+		}
+		opts.FolderID = folder
+		opts.IncludeSubfolders = query.Get("subfolders") == "true"
+	}
+
+	if tags := query["tag"]; len(tags) > 0 {
+		normalized, err := database.NormalizeTags(tags)
+		if err != nil {
+			return opts, errors.New("Invalid tag filter")
+		}
+		opts.Tags = normalized
+	}
+
+	switch opts.DateField = query.Get("dateField"); opts.DateField {
+	case "", database.BoardSortUpdated, database.BoardSortCreated:
+	default:
+		return opts, errors.New("dateField must be created or updated")
+	}
+
+	if from := query.Get("from"); from != "" {
+		t, _, err := parseDateParam(from)
+		if err != nil {
+			return opts, errors.New("Invalid from date")
+		}
+		opts.From = &t
+	}
+	if to := query.Get("to"); to != "" {
+		t, dateOnly, err := parseDateParam(to)
+		if err != nil {
+			return opts, errors.New("Invalid to date")
+		}
+		// A plain date includes the whole day
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		opts.To = &t
+	}
+
+	switch opts.Sort = query.Get("sort"); opts.Sort {
+	case "":
+		opts.Sort = database.BoardSortUpdated
+	case database.BoardSortName, database.BoardSortCreated, database.BoardSortUpdated:
+	default:
+		return opts, errors.New("sort must be name, created or updated")
+	}
+
+	// Names sort A to Z and dates newest first unless told otherwise
+	switch query.Get("order") {
+	case "":
+		opts.Desc = opts.Sort != database.BoardSortName
+	case "asc":
+		opts.Desc = false
+	case "desc":
+		opts.Desc = true
+	default:
+		return opts, errors.New("order must be asc or desc")
+	}
+
+	opts.Limit, _ = strconv.Atoi(query.Get("limit"))
+	if opts.Limit < 0 {
+		opts.Limit = 0
+	}
+	if opts.Limit > maxBoardListLimit {
+		opts.Limit = maxBoardListLimit
+	}
+
+	return opts, nil
+}
+
+// parseDateParam accepts an RFC 3339 timestamp or a YYYY-MM-DD date and
+// reports whether it was a plain date
+func parseDateParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
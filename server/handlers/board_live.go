@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"saas-server/database"
+	"saas-server/middleware"
+	"saas-server/models"
+	"saas-server/pkg/boardpatch"
+	"saas-server/pkg/realtime"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// liveKeepAliveInterval is how often a comment is sent on idle streams so
+// proxies do not close the connection
+const liveKeepAliveInterval = 25 * time.Second
+
+// BoardLiveHandler serves real-time collaborative editing of boards.
+// Sessions receive events over a Server-Sent Events stream and send their
+// operations and presence changes with regular POST requests.
+type BoardLiveHandler struct {
+	DB  *database.DB
+	Hub realtime.Hub
+}
+
+// NewBoardLiveHandler creates a new BoardLiveHandler instance
+func NewBoardLiveHandler(db *database.DB, hub realtime.Hub) *BoardLiveHandler {
+	return &BoardLiveHandler{DB: db, Hub: hub}
+}
+
+// liveHello is the first event sent on a stream
+type liveHello struct {
+	SessionID string              `json:"sessionId"`
+	Version   int                 `json:"version"`
+	Presence  []realtime.Presence `json:"presence"`
+}
+
+// Stream handles GET /api/boards/live and keeps an event stream open for a board
+func (h *BoardLiveHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardLiveHandler] Stream - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get user ID from context using the middleware helper
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardLiveHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, err := boardDB.GetBoard(boardID, userID)
+	if err != nil {
+		log.Printf("[BoardLiveHandler] Board not found or access denied: %v", err)
+		http.Error(w, "Board not found or access denied", http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		log.Printf("[BoardLiveHandler] Streaming not supported by response writer")
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Use the user's name for presence if we can find it
+	name := ""
+	if user, err := h.DB.GetUserByID(userID); err == nil && user != nil {
+		name = user.Name
+	}
+
+	sessionID := uuid.New().String()
+	subscription := h.Hub.Subscribe(boardID, realtime.Presence{
+		SessionID: sessionID,
+		UserID:    userID,
+		Name:      name,
+	})
+	defer subscription.Cancel()
+
+	// Read the version again now that updates are being delivered, so the
+	// hello is never older than an update published before the subscription
+	board, err = boardDB.GetBoard(boardID, userID)
+	if err != nil {
+		log.Printf("[BoardLiveHandler] Board not found or access denied: %v", err)
+		http.Error(w, "Board not found or access denied", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	hello, _ := json.Marshal(liveHello{
+		SessionID: sessionID,
+		Version:   board.Version,
+		Presence:  h.Hub.Presence(boardID),
+	})
+	if err := writeSSE(w, realtime.Event{
+		Type:      realtime.EventHello,
+		BoardID:   boardID,
+		SessionID: sessionID,
+		UserID:    userID,
+		Version:   board.Version,
+		Payload:   hello,
+		Time:      time.Now(),
+	}); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	log.Printf("[BoardLiveHandler] Session %s connected to board %s", sessionID, boardID)
+
+	keepAlive := time.NewTicker(liveKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			log.Printf("[BoardLiveHandler] Session %s disconnected from board %s", sessionID, boardID)
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				log.Printf("[BoardLiveHandler] Session %s was dropped from board %s", sessionID, boardID)
+				return
+			}
+			if err := writeSSE(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// ApplyOps handles POST /api/boards/live/ops. It persists a patch through
+// BoardDB and broadcasts it to every session connected to the board.
+func (h *BoardLiveHandler) ApplyOps(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardLiveHandler] ApplyOps - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, boardID, sessionID, ok := h.requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	// Keep the raw body so it can be broadcast as-is
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		log.Printf("[BoardLiveHandler] Error decoding request body: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var req models.BoardPatchRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		log.Printf("[BoardLiveHandler] Error decoding patch: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		http.Error(w, "Invalid If-Match header", http.StatusBadRequest)
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, err := boardDB.PatchBoard(boardID, userID, req, expectedVersion)
+	if err != nil {
+		switch {
+		case err == database.ErrBoardVersionConflict:
+			writeVersionConflict(w, boardDB, boardID, userID)
+		case errors.Is(err, boardpatch.ErrInvalidPatch):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeBoardError(w, err, "Failed to apply operations")
+		}
+		return
+	}
+
+	h.Hub.Publish(realtime.Event{
+		Type:      realtime.EventOps,
+		BoardID:   boardID,
+		SessionID: sessionID,
+		UserID:    userID,
+		Version:   board.Version,
+		Payload:   raw,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", boardETag(board.Version))
+	json.NewEncoder(w).Encode(models.BoardPatchResponse{
+		ID:        board.ID,
+		Version:   board.Version,
+		NodeCount: len(board.Data.Nodes),
+		EdgeCount: len(board.Data.Edges),
+		UpdatedAt: board.UpdatedAt,
+	})
+}
+
+// UpdatePresence handles POST /api/boards/live/presence with a session's
+// cursor position or selected node
+func (h *BoardLiveHandler) UpdatePresence(w http.ResponseWriter, r *http.Request) {
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, boardID, sessionID, ok := h.requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	var update realtime.PresenceUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Hub.UpdatePresence(boardID, sessionID, userID, update); err != nil {
+		http.Error(w, "Session not connected", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requireSession reads the user, board and session of a live request and
+// checks that the session is connected to the board as that user
+func (h *BoardLiveHandler) requireSession(w http.ResponseWriter, r *http.Request) (string, string, string, bool) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardLiveHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return "", "", "", false
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return "", "", "", false
+	}
+	sessionID, ok := requireUUIDParam(w, r, "session", "Session ID")
+	if !ok {
+		return "", "", "", false
+	}
+
+	for _, presence := range h.Hub.Presence(boardID) {
+		if presence.SessionID == sessionID && presence.UserID == userID {
+			return userID, boardID, sessionID, true
+		}
+	}
+
+	http.Error(w, "Session not connected", http.StatusNotFound)
+	return "", "", "", false
+}
+
+// writeSSE writes a single event in Server-Sent Events format
+func writeSSE(w http.ResponseWriter, event realtime.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
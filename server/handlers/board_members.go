@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"saas-server/database"
+	"saas-server/middleware"
+	"saas-server/models"
+	"saas-server/pkg/email"
+	"saas-server/pkg/validation"
+)
+
+// ListBoardMembers handles requests to list the users a board is shared with
+func (h *BoardHandler) ListBoardMembers(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] ListBoardMembers - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	members, err := boardDB.ListMembers(boardID, userID)
+	if err != nil {
+		writeBoardError(w, err, "Failed to list board members")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(members)
+}
+
+// InviteBoardMember handles requests from a board owner to share the board
+// with another user by email. The response is 202 Accepted whether or not
+// the email has an account; invites to unknown emails are dropped.
+func (h *BoardHandler) InviteBoardMember(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] InviteBoardMember - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+
+	var req models.BoardInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !validation.ValidateEmail(req.Email) {
+		http.Error(w, "Invalid email address", http.StatusBadRequest)
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = models.BoardRoleViewer
+	}
+	if !database.IsValidMemberRole(req.Role) {
+		http.Error(w, "Role must be editor or viewer", http.StatusBadRequest)
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	member, err := boardDB.AddMember(boardID, userID, req.Email, req.Role)
+	switch err {
+	case nil:
+		log.Printf("[BoardHandler] Board %s shared with user %s as %s", boardID, member.UserID, member.Role)
+		h.sendInviteEmail(boardDB, boardID, userID, member)
+	case database.ErrMemberUserNotFound:
+		// Answer as for a known email so the response does not reveal
+		// whether an account exists
+		log.Printf("[BoardHandler] Dropped invite to board %s for an email without an account", boardID)
+	case database.ErrCannotShareWithOwner:
+		http.Error(w, "You already own this board", http.StatusBadRequest)
+		return
+	default:
+		writeBoardError(w, err, "Failed to invite member")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(models.BoardInviteResponse{
+		Email: req.Email,
+		Role:  req.Role,
+	})
+}
+
+// UpdateBoardMemberRole handles requests from a board owner to change a member's role
+func (h *BoardHandler) UpdateBoardMemberRole(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] UpdateBoardMemberRole - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Accept POST and PUT requests
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+
+	var req models.BoardMemberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.UserID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+	if !database.IsValidMemberRole(req.Role) {
+		http.Error(w, "Role must be editor or viewer", http.StatusBadRequest)
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	member, err := boardDB.UpdateMemberRole(boardID, userID, req.UserID, req.Role)
+	if err != nil {
+		if err == database.ErrMemberNotFound {
+			http.Error(w, "Member not found", http.StatusNotFound)
+			return
+		}
+		writeBoardError(w, err, "Failed to update member role")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(member)
+}
+
+// RemoveBoardMember handles requests to revoke a member's access. Owners can
+// remove anyone; members can remove themselves to leave a shared board.
+func (h *BoardHandler) RemoveBoardMember(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] RemoveBoardMember - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Accept POST and DELETE requests
+	if r.Method != http.MethodDelete && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+	memberID, ok := requireUUIDParam(w, r, "userId", "User ID")
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	if err := boardDB.RemoveMember(boardID, userID, memberID); err != nil {
+		if err == database.ErrMemberNotFound {
+			http.Error(w, "Member not found", http.StatusNotFound)
+			return
+		}
+		writeBoardError(w, err, "Failed to remove member")
+		return
+	}
+
+	// Stop streaming the board to the removed member
+	h.Hub.Disconnect(boardID, memberID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Member removed successfully",
+	})
+}
+
+// sendInviteEmail lets a new member know a board was shared with them.
+// Failures are logged and do not affect the invite itself.
+func (h *BoardHandler) sendInviteEmail(boardDB *database.BoardDB, boardID, ownerID string, member *models.BoardMember) {
+	board, err := boardDB.GetBoard(boardID, ownerID)
+	if err != nil {
+		log.Printf("[BoardHandler] Could not load board for invite email: %v", err)
+		return
+	}
+
+	inviterName := "Someone"
+	if owner, err := h.DB.GetUserByID(ownerID); err == nil && owner.Name != "" {
+		inviterName = owner.Name
+	}
+
+	boardURL := fmt.Sprintf("%s/boards/%s", os.Getenv("FRONTEND_URL"), boardID)
+	go func() {
+		if err := email.SendBoardInviteEmail(member.Email, inviterName, board.Name, member.Role, boardURL); err != nil {
+			log.Printf("[BoardHandler] Error sending invite email to %s: %v", member.Email, err)
+		}
+	}()
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"saas-server/database"
+	"saas-server/middleware"
+	"saas-server/models"
+	"saas-server/pkg/boardgraph"
+	"saas-server/pkg/boardmetrics"
+)
+
+// GetBoardMetrics handles GET /api/boards/metrics?id= and returns structure
+// metrics computed from the board's current data, including the orphan and
+// most-connected nodes that are too detailed to cache for board lists
+func (h *BoardHandler) GetBoardMetrics(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] GetBoardMetrics - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, err := boardDB.GetBoard(boardID, userID)
+	if err != nil {
+		writeBoardError(w, err, "Failed to get board metrics")
+		return
+	}
+
+	graph, err := boardgraph.Parse(board.Data)
+	if err != nil {
+		log.Printf("[BoardHandler] Failed to read board %s for metrics: %v", boardID, err)
+		http.Error(w, "Board data could not be read", http.StatusUnprocessableEntity)
+		return
+	}
+	report := boardmetrics.Compute(graph)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", boardETag(board.Version))
+	json.NewEncoder(w).Encode(models.BoardMetricsResponse{
+		BoardID:       board.ID,
+		Version:       board.Version,
+		NodeCount:     len(board.Data.Nodes),
+		EdgeCount:     len(board.Data.Edges),
+		Metrics:       report.BoardMetrics,
+		Orphans:       report.Orphans,
+		MostConnected: report.MostConnected,
+	})
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"saas-server/database"
+	"saas-server/middleware"
+	"saas-server/models"
+)
+
+// ListBoardRevisions handles requests to list the revision history of a board
+func (h *BoardHandler) ListBoardRevisions(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] ListBoardRevisions - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		log.Printf("[BoardHandler] Method not allowed: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get user ID from context using the middleware helper
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	revisions, err := boardDB.ListRevisions(boardID, userID)
+	if err != nil {
+		log.Printf("[BoardHandler] Failed to list revisions: %v", err)
+		http.Error(w, "Board not found or access denied", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("[BoardHandler] Revisions fetched successfully: boardID=%s, count=%d", boardID, len(revisions))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// GetBoardRevision handles requests to fetch the full content of a single revision
+func (h *BoardHandler) GetBoardRevision(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] GetBoardRevision - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		log.Printf("[BoardHandler] Method not allowed: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get user ID from context using the middleware helper
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+	revisionID, ok := requireUUIDParam(w, r, "revisionId", "Revision ID")
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	revision, err := boardDB.GetRevision(boardID, revisionID, userID)
+	if err != nil {
+		if err == database.ErrRevisionNotFound {
+			http.Error(w, "Revision not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("[BoardHandler] Failed to get revision: %v", err)
+		http.Error(w, "Board not found or access denied", http.StatusNotFound)
+		return
+	}
+
+	response := models.BoardRevisionResponse{
+		ID:          revision.ID,
+		BoardID:     revision.BoardID,
+		UserID:      revision.UserID,
+		Name:        revision.Name,
+		Description: revision.Description,
+		Nodes:       decodeRawMessages(revision.Data.Nodes),
+		Edges:       decodeRawMessages(revision.Data.Edges),
+		CreatedAt:   revision.CreatedAt,
+	}
+
+	log.Printf("[BoardHandler] Revision fetched successfully: boardID=%s, revisionID=%s", boardID, revisionID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RestoreBoardRevision handles requests to restore a board to one of its revisions
+func (h *BoardHandler) RestoreBoardRevision(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] RestoreBoardRevision - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		log.Printf("[BoardHandler] Method not allowed: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get user ID from context using the middleware helper
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+	revisionID, ok := requireUUIDParam(w, r, "revisionId", "Revision ID")
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, err := boardDB.RestoreRevision(boardID, revisionID, userID)
+	if err != nil {
+		if err == database.ErrRevisionNotFound {
+			http.Error(w, "Revision not found", http.StatusNotFound)
+			return
+		}
+		writeBoardError(w, err, "Failed to restore revision")
+		return
+	}
+	h.publishBoardUpdate(board, userID)
+
+	log.Printf("[BoardHandler] Board restored successfully: id=%s, revisionID=%s", boardID, revisionID)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", boardETag(board.Version))
+	json.NewEncoder(w).Encode(newBoardResponse(board))
+}
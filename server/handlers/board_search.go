@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html"
+	"log"
+	"net/http"
+	"saas-server/database"
+	"saas-server/middleware"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// defaultSearchLimit is the number of results returned when no limit is given
+	defaultSearchLimit = 20
+	// maxSearchLimit caps the number of results a single search can return
+	maxSearchLimit = 50
+	// maxSearchQueryLength caps the length of a search query in characters
+	maxSearchQueryLength = 200
+)
+
+// SearchBoards handles GET /api/boards/search?q= across all boards the user can access
+func (h *BoardHandler) SearchBoards(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] SearchBoards - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "Search query is required", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		http.Error(w, "Search query is too long", http.StatusBadRequest)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	results, err := boardDB.SearchBoards(userID, query, limit)
+	if err != nil {
+		log.Printf("[BoardHandler] Failed to search boards: %v", err)
+		http.Error(w, "Failed to search boards", http.StatusInternalServerError)
+		return
+	}
+
+	for i := range results {
+		results[i].Snippet = escapeSnippet(results[i].Snippet)
+	}
+
+	log.Printf("[BoardHandler] Search returned %d boards", len(results))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// escapeSnippet HTML-escapes a search headline while keeping its <mark> highlights,
+// so clients can render it as HTML without trusting board content
+func escapeSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, "&lt;mark&gt;", "<mark>")
+	return strings.ReplaceAll(escaped, "&lt;/mark&gt;", "</mark>")
+}
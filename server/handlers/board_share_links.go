@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"saas-server/database"
+	"saas-server/middleware"
+	"saas-server/models"
+	"saas-server/pkg/analytics"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// shareLinkPasswordHeader carries the password for a protected share link
+const shareLinkPasswordHeader = "X-Share-Password"
+
+// CreateBoardShareLink handles requests from a board owner to create a public read-only link
+func (h *BoardHandler) CreateBoardShareLink(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] CreateBoardShareLink - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+
+	// The body is optional; an empty body creates a link without expiry or password
+	var req models.BoardShareLinkCreateRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		http.Error(w, "Expiry must be in the future", http.StatusBadRequest)
+		return
+	}
+
+	passwordHash := ""
+	if req.Password != "" {
+		if len(req.Password) < 4 {
+			http.Error(w, "Password must be at least 4 characters long", http.StatusBadRequest)
+			return
+		}
+		hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			log.Printf("[BoardHandler] Error hashing share link password: %v", err)
+			http.Error(w, "Failed to create share link", http.StatusInternalServerError)
+			return
+		}
+		passwordHash = string(hashed)
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	link, err := boardDB.CreateShareLink(boardID, userID, passwordHash, req.ExpiresAt)
+	if err != nil {
+		writeBoardError(w, err, "Failed to create share link")
+		return
+	}
+	link.URL = shareLinkURL(link.Token)
+
+	log.Printf("[BoardHandler] Share link created: boardID=%s, linkID=%s", boardID, link.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(link)
+}
+
+// ListBoardShareLinks handles requests from a board owner to list its share links
+func (h *BoardHandler) ListBoardShareLinks(w http.ResponseWriter, r *http.Request) {
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	links, err := boardDB.ListShareLinks(boardID, userID)
+	if err != nil {
+		writeBoardError(w, err, "Failed to list share links")
+		return
+	}
+
+	for i := range links {
+		links[i].URL = shareLinkURL(links[i].Token)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
+
+// RevokeBoardShareLink handles requests from a board owner to disable a share link
+func (h *BoardHandler) RevokeBoardShareLink(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] RevokeBoardShareLink - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Accept POST and DELETE requests
+	if r.Method != http.MethodDelete && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+	linkID, ok := requireUUIDParam(w, r, "linkId", "Link ID")
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	if err := boardDB.RevokeShareLink(boardID, userID, linkID); err != nil {
+		if err == database.ErrShareLinkNotFound {
+			http.Error(w, "Share link not found", http.StatusNotFound)
+			return
+		}
+		writeBoardError(w, err, "Failed to revoke share link")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Share link revoked successfully",
+	})
+}
+
+// GetPublicBoard handles anonymous requests to view a board through a share link.
+// Protected links require the password in the X-Share-Password header.
+func (h *BoardHandler) GetPublicBoard(w http.ResponseWriter, r *http.Request) {
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	board, ok := h.resolveShareLink(w, r)
+	if !ok {
+		return
+	}
+
+	// Count the view; a failure here should not stop the board from loading
+	pageView := analytics.NewPageView(
+		nil,
+		fmt.Sprintf("v_%d", time.Now().UnixNano()),
+		"/public/boards/"+board.ID,
+		r.Referer(),
+		r.UserAgent(),
+		r.RemoteAddr,
+	)
+	if err := h.DB.TrackPageView(pageView); err != nil {
+		log.Printf("[BoardHandler] Failed to track public board view: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(newBoardResponse(board))
+}
+
+// resolveShareLink loads the board behind the share token in the "token" query
+// parameter, checking expiry and the X-Share-Password header. On failure it
+// writes the response and returns false.
+func (h *BoardHandler) resolveShareLink(w http.ResponseWriter, r *http.Request) (*models.Board, bool) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "Share token is required", http.StatusBadRequest)
+		return nil, false
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	link, board, err := boardDB.GetSharedBoard(token)
+	if err != nil {
+		switch err {
+		case database.ErrShareLinkNotFound:
+			http.Error(w, "Share link not found", http.StatusNotFound)
+		case database.ErrShareLinkExpired:
+			http.Error(w, "Share link has expired", http.StatusGone)
+		default:
+			log.Printf("[BoardHandler] Failed to resolve share link: %v", err)
+			http.Error(w, "Failed to load board", http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+
+	if link.HasPassword {
+		password := r.Header.Get(shareLinkPasswordHeader)
+		if password == "" || bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error":   "password_required",
+				"message": "This board is protected by a password",
+			})
+			return nil, false
+		}
+	}
+
+	return board, true
+}
+
+// shareLinkURL builds the client URL for a share token
+func shareLinkURL(token string) string {
+	return fmt.Sprintf("%s/share/%s", os.Getenv("FRONTEND_URL"), token)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"saas-server/database"
+	"saas-server/models"
+	"saas-server/pkg/ai"
+)
+
+// ApplyBoardSuggestions handles POST /api/boards/{id}/suggestions. The body
+// holds brainstorm suggestions, in the shape returned by the brainstorm
+// endpoints, and the ID of the node to add them under. All nodes and edges
+// are created in one board update, which honours If-Match like the node
+// routes; the response lists their IDs so the client can undo the change.
+func (h *BoardHandler) ApplyBoardSuggestions(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] ApplyBoardSuggestions - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	userID, boardID, ok := boardElementRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.BrainstormApplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ParentID == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	suggestions, err := ai.CleanSuggestions(req.Suggestions)
+	if err != nil {
+		log.Printf("[BoardHandler] Rejected suggestions for board %s: %v", boardID, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	expectedVersion, ok := elementIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, nodeIDs, edgeIDs, err := boardDB.ApplySuggestions(boardID, userID, req.ParentID, suggestions, expectedVersion)
+	if err != nil {
+		writeBoardElementError(w, boardDB, boardID, userID, err, "Failed to apply suggestions")
+		return
+	}
+	h.publishBoardUpdate(board, userID)
+
+	log.Printf("[BoardHandler] Applied %d suggested nodes to board %s", len(nodeIDs), boardID)
+	writeBoardElement(w, http.StatusCreated, board.Version, models.BrainstormApplyResponse{
+		BoardID: board.ID,
+		Version: board.Version,
+		NodeIDs: nodeIDs,
+		EdgeIDs: edgeIDs,
+	})
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"saas-server/database"
+	"saas-server/middleware"
+	"saas-server/models"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// maxTemplateCategoryLength matches the board_templates.category column
+const maxTemplateCategoryLength = 50
+
+// ListBoardTemplates handles GET /api/boards/templates, returning the system
+// templates and the user's own templates without their content
+func (h *BoardHandler) ListBoardTemplates(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] ListBoardTemplates - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	templates, err := database.NewBoardDB(h.DB.DB).ListTemplates(userID)
+	if err != nil {
+		log.Printf("[BoardHandler] Failed to list templates for user %s: %v", userID, err)
+		http.Error(w, "Failed to list templates", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// GetBoardTemplate handles GET /api/boards/templates/get?id=, returning a
+// system template or one of the user's templates with its content
+func (h *BoardHandler) GetBoardTemplate(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] GetBoardTemplate - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	templateID, ok := requireUUIDParam(w, r, "id", "Template ID")
+	if !ok {
+		return
+	}
+
+	template, err := database.NewBoardDB(h.DB.DB).GetTemplate(templateID, userID)
+	if err != nil {
+		writeTemplateError(w, err, "Failed to get template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+// SaveBoardAsTemplate handles POST /api/boards/templates/create, saving a copy
+// of a board the user can read, given by boardId, as a private template
+func (h *BoardHandler) SaveBoardAsTemplate(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] SaveBoardAsTemplate - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	req, ok := decodeTemplateRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := uuid.Parse(req.BoardID); err != nil {
+		http.Error(w, "A valid board ID is required", http.StatusBadRequest)
+		return
+	}
+
+	template, err := database.NewBoardDB(h.DB.DB).SaveBoardAsTemplate(req.BoardID, userID, req)
+	if err != nil {
+		writeBoardError(w, err, "Failed to save template")
+		return
+	}
+
+	log.Printf("[BoardHandler] Board %s saved as template %s by user %s", req.BoardID, template.ID, userID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(template)
+}
+
+// DeleteBoardTemplate handles /api/boards/templates/delete?id=. Users
+// can only delete their own templates.
+func (h *BoardHandler) DeleteBoardTemplate(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] DeleteBoardTemplate - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	// Only accept DELETE or POST requests
+	if r.Method != http.MethodDelete && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	templateID, ok := requireUUIDParam(w, r, "id", "Template ID")
+	if !ok {
+		return
+	}
+
+	if err := database.NewBoardDB(h.DB.DB).DeleteTemplate(templateID, userID); err != nil {
+		writeTemplateError(w, err, "Failed to delete template")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminListTemplates handles GET /admin/templates, returning the system templates
+func (h *BoardHandler) AdminListTemplates(w http.ResponseWriter, r *http.Request) {
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	templates, err := database.NewBoardDB(h.DB.DB).ListSystemTemplates()
+	if err != nil {
+		log.Printf("[BoardHandler] Failed to list system templates: %v", err)
+		http.Error(w, "Failed to list templates", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// AdminCreateTemplate handles POST /admin/templates/create, publishing a
+// system template from the board data in the request body
+func (h *BoardHandler) AdminCreateTemplate(w http.ResponseWriter, r *http.Request) {
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, ok := decodeTemplateRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if req.Data == nil {
+		http.Error(w, "Template data is required", http.StatusBadRequest)
+		return
+	}
+
+	template, err := database.NewBoardDB(h.DB.DB).CreateSystemTemplate(req, *req.Data)
+	if err != nil {
+		writeTemplateError(w, err, "Failed to create template")
+		return
+	}
+
+	log.Printf("[BoardHandler] System template %s created", template.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(template)
+}
+
+// AdminUpdateTemplate handles /admin/templates/update?id=. The template's
+// content is only replaced when data is present in the body.
+func (h *BoardHandler) AdminUpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	// Only accept POST or PUT requests
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	templateID, ok := requireUUIDParam(w, r, "id", "Template ID")
+	if !ok {
+		return
+	}
+
+	req, ok := decodeTemplateRequest(w, r)
+	if !ok {
+		return
+	}
+
+	template, err := database.NewBoardDB(h.DB.DB).UpdateSystemTemplate(templateID, req, req.Data)
+	if err != nil {
+		writeTemplateError(w, err, "Failed to update template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+// AdminDeleteTemplate handles /admin/templates/delete?id=. Boards
+// created from the template are not affected.
+func (h *BoardHandler) AdminDeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	// Only accept DELETE or POST requests
+	if r.Method != http.MethodDelete && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	templateID, ok := requireUUIDParam(w, r, "id", "Template ID")
+	if !ok {
+		return
+	}
+
+	if err := database.NewBoardDB(h.DB.DB).DeleteSystemTemplate(templateID); err != nil {
+		writeTemplateError(w, err, "Failed to delete template")
+		return
+	}
+
+	log.Printf("[BoardHandler] System template %s deleted", templateID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeTemplateRequest reads and validates a template payload
+func decodeTemplateRequest(w http.ResponseWriter, r *http.Request) (models.BoardTemplateRequest, bool) {
+	var req models.BoardTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return req, false
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	req.Category = strings.ToLower(strings.TrimSpace(req.Category))
+
+	if req.Name == "" {
+		http.Error(w, "Template name is required", http.StatusBadRequest)
+		return req, false
+	}
+	if utf8.RuneCountInString(req.Name) > maxBoardNameLength {
+		http.Error(w, "Template name must be at most 255 characters", http.StatusBadRequest)
+		return req, false
+	}
+	if utf8.RuneCountInString(req.Category) > maxTemplateCategoryLength {
+		http.Error(w, "Template category must be at most 50 characters", http.StatusBadRequest)
+		return req, false
+	}
+
+	return req, true
+}
+
+// writeTemplateError maps template errors to HTTP responses
+func writeTemplateError(w http.ResponseWriter, err error, fallback string) {
+	if err == database.ErrTemplateNotFound {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+	writeBoardError(w, err, fallback)
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"saas-server/database"
+	"saas-server/middleware"
+)
+
+// ListTrashedBoards handles requests to list the boards in the user's trash
+func (h *BoardHandler) ListTrashedBoards(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] ListTrashedBoards - Method: %s, Path: %s", r.Method, r.URL.Path)
+
+	// Only accept GET requests
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	boards, err := boardDB.ListTrashedBoards(userID, database.BoardTrashRetention())
+	if err != nil {
+		log.Printf("[BoardHandler] Failed to list trashed boards: %v", err)
+		http.Error(w, "Failed to list trashed boards", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(boards)
+}
+
+// RestoreTrashedBoard handles requests to move a board out of the trash
+func (h *BoardHandler) RestoreTrashedBoard(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] RestoreTrashedBoard - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Only accept POST requests
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	board, err := boardDB.RestoreBoard(boardID, userID)
+	if err != nil {
+		if err == database.ErrBoardNotFound {
+			http.Error(w, "Board not found in trash", http.StatusNotFound)
+			return
+		}
+		writeBoardError(w, err, "Failed to restore board")
+		return
+	}
+
+	log.Printf("[BoardHandler] Board restored from trash: id=%s", boardID)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", boardETag(board.Version))
+	json.NewEncoder(w).Encode(newBoardResponse(board))
+}
+
+// PurgeTrashedBoard handles requests to permanently delete a board from the trash
+func (h *BoardHandler) PurgeTrashedBoard(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[BoardHandler] PurgeTrashedBoard - Method: %s, Path: %s, Query: %s",
+		r.Method, r.URL.Path, r.URL.RawQuery)
+
+	// Accept POST and DELETE requests
+	if r.Method != http.MethodDelete && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		log.Printf("[BoardHandler] Error: user_id not found in context")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	boardID, ok := requireUUIDParam(w, r, "id", "Board ID")
+	if !ok {
+		return
+	}
+
+	boardDB := database.NewBoardDB(h.DB.DB)
+	if err := boardDB.PurgeBoard(boardID, userID); err != nil {
+		if err == database.ErrBoardNotFound {
+			http.Error(w, "Board not found in trash", http.StatusNotFound)
+			return
+		}
+		log.Printf("[BoardHandler] Failed to purge board: %v", err)
+		http.Error(w, "Failed to delete board", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("[BoardHandler] Board permanently deleted: id=%s", boardID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Board permanently deleted",
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"saas-server/database"
 	"saas-server/middleware"
+	"saas-server/pkg/ai"
 	"saas-server/pkg/encryption"
 	"strings"
 )
@@ -27,7 +28,8 @@ type ProviderSettings struct {
 	SelectedModel string   `json:"selectedModel,omitempty"`
 }
 
-// GetAPIKeys returns the saved API keys for the current user
+// GetAPIKeys returns the AI settings of the current user with each saved API
+// key masked. Keys never leave the server; AI requests go through /api/ai.
 func (h *SettingsHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context using the middleware helper
 	userIDStr := middleware.GetUserID(r.Context())
@@ -69,34 +71,14 @@ func (h *SettingsHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create response with masked keys
+	// Create response with masked keys. Keys are only ever used by the
+	// server, so the browser just learns whether one is saved and its last
+	// few characters to tell keys apart.
 	maskedSettings := make(map[string]interface{})
 	for provider, data := range aiSettings {
-		// Pass the encrypted key directly - don't try to decrypt or mask it
-		// The client will handle decryption
-		encryptedKey := data.Key
-
-		// Validate that the key appears to be a valid Base64 encrypted value
-		// and not a masked key (e.g., with bullet points) or Bearer prefixed key
-		validBase64 := true
-		if len(encryptedKey) > 0 {
-			for _, c := range encryptedKey {
-				// Check if character is valid Base64 (A-Z, a-z, 0-9, +, /, or =)
-				if !((c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') ||
-					(c >= '0' && c <= '9') || c == '+' || c == '/' || c == '=') {
-					validBase64 = false
-					break
-				}
-			}
-		}
-
-		// If the key contains "Bearer" or bullet points or isn't valid Base64,
-		// clear it to force the user to re-enter a valid key
-		if !validBase64 ||
-			len(encryptedKey) < 10 ||
-			encryptedKey == "" {
-			log.Printf("[SettingsHandler] Invalid key format for provider %s - will be cleared", provider)
-			encryptedKey = ""
+		maskedKey := maskAPIKey(data.Key)
+		if maskedKey == "" && data.Key != "" {
+			log.Printf("[SettingsHandler] Saved key for provider %s cannot be decrypted - will be cleared", provider)
 		}
 
 		// Ensure models is not null
@@ -137,13 +119,13 @@ func (h *SettingsHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		// Prepare response using encrypted key
-		log.Printf("[SettingsHandler] Sending response for provider %s: encryptedKey masked in log, isValid=true, models=%v, selectedModel=%s",
-			provider, models, selectedModel)
+		log.Printf("[SettingsHandler] Sending response for provider %s: hasKey=%t, models=%v, selectedModel=%s",
+			provider, maskedKey != "", models, selectedModel)
 
 		maskedSettings[provider] = map[string]interface{}{
-			"key":           encryptedKey,
-			"isValid":       true,
+			"key":           maskedKey,
+			"hasKey":        maskedKey != "",
+			"isValid":       maskedKey != "",
 			"models":        models,
 			"selectedModel": selectedModel,
 		}
@@ -180,8 +162,29 @@ func (h *SettingsHandler) SaveKeys(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Keys sent back still masked as returned by GetAPIKeys keep their saved value
+	var savedSettings map[string]ProviderSettings
+	if settings, err := h.DB.GetUserSettings(userIDStr); err == nil && settings != nil && len(settings.AISettings) > 0 {
+		if err := settings.AISettings.Unmarshal(&savedSettings); err != nil {
+			log.Printf("[SettingsHandler] Error parsing saved AI settings: %v", err)
+		}
+	}
+
 	// Encrypt keys before storage
 	for provider, data := range keysToSave {
+		if saved, ok := savedSettings[provider]; ok && data.Key != "" && data.Key == maskAPIKey(saved.Key) {
+			data.Key = saved.Key
+			keysToSave[provider] = data
+			continue
+		}
+
+		// Providers that need no key may be saved without one, to keep the selected model
+		if data.Key == "" {
+			if registered, err := ai.Lookup(provider); err == nil && !registered.RequiresKey() {
+				continue
+			}
+		}
+
 		// Clean up the key before encrypting - remove Bearer prefix, whitespace, and non-printable chars
 		cleanKey := data.Key
 
@@ -228,3 +231,22 @@ func (h *SettingsHandler) SaveKeys(w http.ResponseWriter, r *http.Request) {
 		"message": "API keys saved successfully",
 	})
 }
+
+// maskAPIKey decrypts a saved key and returns it masked except for its last
+// four characters, or "" when there is no usable key
+func maskAPIKey(encryptedKey string) string {
+	if encryptedKey == "" {
+		return ""
+	}
+
+	apiKey, err := encryption.Decrypt(encryptedKey)
+	if err != nil {
+		return ""
+	}
+	apiKey = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(apiKey), "Bearer "))
+	if len(apiKey) < 10 {
+		return ""
+	}
+
+	return strings.Repeat("•", 8) + apiKey[len(apiKey)-4:]
+}
@@ -95,6 +95,14 @@ func (h *UserDataHandler) GetUserSubscription(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Include the plan's board limits and how much of them is used
+	quota, err := h.DB.GetBoardQuota(userID)
+	if err != nil {
+		log.Printf("[UserData] Error getting board quota for user %s: %v", userID, err)
+	} else {
+		subscription.BoardQuota = quota
+	}
+
 	json.NewEncoder(w).Encode([]models.Subscription{*subscription})
 }
 
@@ -14,6 +14,9 @@ import (
 	"saas-server/database"
 	"saas-server/handlers"
 	"saas-server/middleware"
+	"saas-server/pkg/ai"
+	"saas-server/pkg/cleanup"
+	"saas-server/pkg/realtime"
 
 	"github.com/joho/godotenv"
 	"github.com/rs/cors"
@@ -64,6 +67,9 @@ func main() {
 	}
 	log.Println("Database migrations applied successfully")
 
+	// Permanently delete boards that have been in the trash past the retention period
+	cleanup.NewBoardTrashPurgeService(database.NewBoardDB(db.DB), database.BoardTrashRetention()).StartPurgeJob()
+
 	// Initialize handlers and middleware
 	authHandler := handlers.NewAuthHandler(db, os.Getenv("JWT_SECRET"))
 	authMiddleware := middleware.NewAuthMiddleware(db, os.Getenv("JWT_SECRET"))
@@ -114,7 +120,9 @@ func main() {
 	mux.Handle("/api/user/subscription/billing", authMiddleware.RequireAuth(http.HandlerFunc(userDataHandler.GetBillingPortal)))
 
 	// Board API routes (protected with auth, subscription check, and rate limiting)
-	boardHandler := handlers.NewBoardHandler(db)
+	// Board writes are announced to the sessions connected through the live routes
+	boardHub := realtime.NewInProcessHub()
+	boardHandler := handlers.NewBoardHandler(db, boardHub)
 	subscriptionMiddleware := middleware.NewSubscriptionMiddleware(db)
 
 	// Regular rate limiter for board API (e.g., 60 requests per minute)
@@ -132,10 +140,45 @@ func main() {
 		subscriptionMiddleware.HasActiveSubscription(
 			boardWriteRateLimiter.Limit(http.HandlerFunc(settingsHandler.SaveKeys)))))
 
+	// AI routes call the user's provider with their saved key, so the key never reaches the browser
+	if ollamaURL := os.Getenv("OLLAMA_BASE_URL"); ollamaURL != "" {
+		ai.Register(ai.NewKeylessOpenAICompatible(ai.ProviderOllama, ollamaURL, ai.HTTPClient))
+	}
+	aiHandler := handlers.NewAIHandler(db)
+	aiRateLimiter := middleware.NewRateLimiter(1*time.Minute, 10)
+	// Brainstorm requests also count against the plan's monthly AI limits
+	mux.Handle("/api/ai/brainstorm", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			subscriptionMiddleware.WithinAILimits(
+				aiRateLimiter.Limit(http.HandlerFunc(aiHandler.Brainstorm))))))
+
+	// Streams tokens and each completed concept as Server-Sent Events; closing the connection cancels the request
+	mux.Handle("/api/ai/brainstorm/stream", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			subscriptionMiddleware.WithinAILimits(
+				aiRateLimiter.Limit(http.HandlerFunc(aiHandler.StreamBrainstorm))))))
+
+	// Listing models is recorded but not limited, so settings keep working once the limits are reached
+	mux.Handle("/api/ai/models", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardRateLimiter.Limit(http.HandlerFunc(aiHandler.ListModels)))))
+
+	// Monthly AI requests and tokens against the plan's limits
+	mux.Handle("/api/user/ai-usage", authMiddleware.RequireAuth(http.HandlerFunc(aiHandler.GetAIUsage)))
+
 	// Routes with subscription requirement
 	mux.Handle("/api/boards/list", authMiddleware.RequireAuth(
 		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.ListBoards))))
 
+	mux.Handle("/api/boards/search", authMiddleware.RequireAuth(
+		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.SearchBoards))))
+
+	mux.Handle("/api/boards/export", authMiddleware.RequireAuth(
+		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.ExportBoard))))
+
+	mux.Handle("/api/boards/metrics", authMiddleware.RequireAuth(
+		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.GetBoardMetrics))))
+
 	mux.Handle("/api/boards/get", authMiddleware.RequireAuth(
 		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.GetBoard))))
 
@@ -144,6 +187,14 @@ func main() {
 		subscriptionMiddleware.HasActiveSubscription(
 			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.CreateBoard)))))
 
+	mux.Handle("/api/boards/import", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.ImportBoard)))))
+
+	mux.Handle("/api/boards/duplicate", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.DuplicateBoard)))))
+
 	// Update route with the same updated middleware
 	mux.Handle("/api/boards/update", authMiddleware.RequireAuth(
 		subscriptionMiddleware.HasActiveSubscription(
@@ -153,6 +204,181 @@ func main() {
 		subscriptionMiddleware.HasActiveSubscription(
 			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.DeleteBoard)))))
 
+	// Folder and tag routes for organising boards
+	mux.Handle("/api/boards/folders", authMiddleware.RequireAuth(
+		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.ListBoardFolders))))
+
+	mux.Handle("/api/boards/folders/create", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.CreateBoardFolder)))))
+
+	mux.Handle("/api/boards/folders/update", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.UpdateBoardFolder)))))
+
+	mux.Handle("/api/boards/folders/delete", authMiddleware.RequireAuth(
+		boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.DeleteBoardFolder))))
+
+	mux.Handle("/api/boards/move", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.MoveBoardToFolder)))))
+
+	mux.Handle("/api/boards/tags", authMiddleware.RequireAuth(
+		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.ListBoardTags))))
+
+	mux.Handle("/api/boards/tags/set", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.SetBoardTags)))))
+
+	// Template routes; users can start boards from system templates or save their own
+	mux.Handle("/api/boards/templates", authMiddleware.RequireAuth(
+		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.ListBoardTemplates))))
+
+	mux.Handle("/api/boards/templates/get", authMiddleware.RequireAuth(
+		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.GetBoardTemplate))))
+
+	mux.Handle("/api/boards/templates/create", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.SaveBoardAsTemplate)))))
+
+	mux.Handle("/api/boards/templates/delete", authMiddleware.RequireAuth(
+		boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.DeleteBoardTemplate))))
+
+	// Trash routes; deleted boards can be restored until they are purged
+	mux.Handle("/api/boards/trash", authMiddleware.RequireAuth(
+		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.ListTrashedBoards))))
+
+	mux.Handle("/api/boards/trash/restore", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.RestoreTrashedBoard)))))
+
+	mux.Handle("/api/boards/trash/delete", authMiddleware.RequireAuth(
+		boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.PurgeTrashedBoard))))
+
+	// Incremental updates are small and frequent, so they get a more generous limit
+	boardPatchRateLimiter := middleware.NewRateLimiter(1*time.Minute, 120)
+
+	mux.Handle("/api/boards/patch", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardPatchRateLimiter.Limit(http.HandlerFunc(boardHandler.PatchBoard)))))
+
+	// Automatic layout; dryRun=true previews positions without saving them
+	mux.Handle("/api/boards/layout", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.LayoutBoard)))))
+
+	// Single node and edge routes for scripts and integrations. Deleting a node
+	// also deletes its edges; a node can be created under a parent node.
+	mux.Handle("GET /api/boards/{id}/nodes/{nodeId}", authMiddleware.RequireAuth(
+		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.GetBoardNode))))
+
+	mux.Handle("POST /api/boards/{id}/nodes", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardPatchRateLimiter.Limit(http.HandlerFunc(boardHandler.CreateBoardNode)))))
+
+	mux.Handle("PATCH /api/boards/{id}/nodes/{nodeId}", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardPatchRateLimiter.Limit(http.HandlerFunc(boardHandler.UpdateBoardNode)))))
+
+	mux.Handle("DELETE /api/boards/{id}/nodes/{nodeId}", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardPatchRateLimiter.Limit(http.HandlerFunc(boardHandler.DeleteBoardNode)))))
+
+	mux.Handle("GET /api/boards/{id}/edges/{edgeId}", authMiddleware.RequireAuth(
+		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.GetBoardEdge))))
+
+	mux.Handle("POST /api/boards/{id}/edges", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardPatchRateLimiter.Limit(http.HandlerFunc(boardHandler.CreateBoardEdge)))))
+
+	mux.Handle("PATCH /api/boards/{id}/edges/{edgeId}", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardPatchRateLimiter.Limit(http.HandlerFunc(boardHandler.UpdateBoardEdge)))))
+
+	mux.Handle("DELETE /api/boards/{id}/edges/{edgeId}", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardPatchRateLimiter.Limit(http.HandlerFunc(boardHandler.DeleteBoardEdge)))))
+
+	// Adds brainstorm suggestions under a node in a single board update
+	mux.Handle("POST /api/boards/{id}/suggestions", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.ApplyBoardSuggestions)))))
+
+	// Real-time collaboration routes: an event stream per board plus POSTs for
+	// operations and presence. Cursor updates are frequent, so presence has its own limiter.
+	boardLiveHandler := handlers.NewBoardLiveHandler(db, boardHub)
+	boardPresenceRateLimiter := middleware.NewRateLimiter(1*time.Minute, 600)
+
+	mux.Handle("/api/boards/live", authMiddleware.RequireAuth(
+		boardRateLimiter.Limit(http.HandlerFunc(boardLiveHandler.Stream))))
+
+	mux.Handle("/api/boards/live/ops", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardPatchRateLimiter.Limit(http.HandlerFunc(boardLiveHandler.ApplyOps)))))
+
+	mux.Handle("/api/boards/live/presence", authMiddleware.RequireAuth(
+		boardPresenceRateLimiter.Limit(http.HandlerFunc(boardLiveHandler.UpdatePresence))))
+
+	// Board sharing routes; invites, role changes and removals are owner-only except leaving a board
+	mux.Handle("/api/boards/members", authMiddleware.RequireAuth(
+		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.ListBoardMembers))))
+
+	mux.Handle("/api/boards/members/invite", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.InviteBoardMember)))))
+
+	mux.Handle("/api/boards/members/role", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.UpdateBoardMemberRole)))))
+
+	mux.Handle("/api/boards/members/remove", authMiddleware.RequireAuth(
+		boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.RemoveBoardMember))))
+
+	// Node comment routes; anyone with access may comment, authors edit their own comments
+	// and the owner may delete any of them
+	mux.Handle("/api/boards/comments", authMiddleware.RequireAuth(
+		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.ListBoardComments))))
+
+	mux.Handle("/api/boards/comments/create", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.CreateBoardComment)))))
+
+	mux.Handle("/api/boards/comments/update", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.UpdateBoardComment)))))
+
+	mux.Handle("/api/boards/comments/resolve", authMiddleware.RequireAuth(
+		boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.ResolveBoardComment))))
+
+	mux.Handle("/api/boards/comments/delete", authMiddleware.RequireAuth(
+		boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.DeleteBoardComment))))
+
+	// Public share link management (owner only)
+	mux.Handle("/api/boards/share-links", authMiddleware.RequireAuth(
+		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.ListBoardShareLinks))))
+
+	mux.Handle("/api/boards/share-links/create", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.CreateBoardShareLink)))))
+
+	mux.Handle("/api/boards/share-links/revoke", authMiddleware.RequireAuth(
+		boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.RevokeBoardShareLink))))
+
+	// Anonymous read-only access through a share link; rate-limited to slow down password guessing
+	publicBoardRateLimiter := middleware.NewRateLimiter(1*time.Minute, 30)
+	mux.Handle("/api/public/boards", publicBoardRateLimiter.Limit(http.HandlerFunc(boardHandler.GetPublicBoard)))
+
+	// Board revision history routes
+	mux.Handle("/api/boards/revisions", authMiddleware.RequireAuth(
+		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.ListBoardRevisions))))
+
+	mux.Handle("/api/boards/revisions/get", authMiddleware.RequireAuth(
+		boardRateLimiter.Limit(http.HandlerFunc(boardHandler.GetBoardRevision))))
+
+	mux.Handle("/api/boards/revisions/restore", authMiddleware.RequireAuth(
+		subscriptionMiddleware.HasActiveSubscription(
+			boardWriteRateLimiter.Limit(http.HandlerFunc(boardHandler.RestoreBoardRevision)))))
+
 	// Analytics routes (public)
 	mux.HandleFunc("/api/analytics/pageview", analyticsHandler.TrackPageView)
 
@@ -160,6 +386,15 @@ func main() {
 	mux.HandleFunc("/admin/login", adminHandler.Login)
 	mux.Handle("/admin/users", adminMiddleware.RequireAdmin(http.HandlerFunc(adminHandler.GetUsers)))
 
+	// System board templates, published by admins for every user
+	mux.Handle("/admin/templates", adminMiddleware.RequireAdmin(http.HandlerFunc(boardHandler.AdminListTemplates)))
+	mux.Handle("/admin/templates/create", adminMiddleware.RequireAdmin(http.HandlerFunc(boardHandler.AdminCreateTemplate)))
+	mux.Handle("/admin/templates/update", adminMiddleware.RequireAdmin(http.HandlerFunc(boardHandler.AdminUpdateTemplate)))
+	mux.Handle("/admin/templates/delete", adminMiddleware.RequireAdmin(http.HandlerFunc(boardHandler.AdminDeleteTemplate)))
+
+	// AI usage by provider, model and user for a month
+	mux.Handle("/admin/ai-usage", adminMiddleware.RequireAdmin(http.HandlerFunc(aiHandler.AdminAIUsageReport)))
+
 	// Admin health check endpoint (for connection testing)
 	mux.HandleFunc("/admin/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -209,9 +444,9 @@ func main() {
 			os.Getenv("FRONTEND_URL"),
 			os.Getenv("BETA_CLIENT_URL"),
 		},
-		AllowedMethods:      []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:      []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Requested-With"},
-		ExposedHeaders:      []string{"Link"},
+		AllowedMethods:      []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:      []string{"Accept", "Authorization", "Content-Type", "If-Match", "X-CSRF-Token", "X-Requested-With", "X-Share-Password"},
+		ExposedHeaders:      []string{"ETag", "Link"},
 		AllowCredentials:    true,
 		MaxAge:              300, // Maximum value not ignored by any of major browsers
 		AllowPrivateNetwork: true,
@@ -2,10 +2,15 @@ package middleware
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"saas-server/database"
+	"saas-server/models"
+	"strconv"
+	"time"
 )
 
 // SubscriptionMiddleware checks if users have an active subscription
@@ -85,3 +90,48 @@ func (sm *SubscriptionMiddleware) HasActiveSubscription(next http.Handler) http.
 		next.ServeHTTP(w, r)
 	})
 }
+
+// WithinAILimits middleware checks that the user has not used up their plan's
+// monthly AI requests or tokens and returns 429 Too Many Requests if they have.
+// Like HasActiveSubscription, it lets requests through in development mode
+// when the check itself fails.
+func (sm *SubscriptionMiddleware) WithinAILimits(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		if userID == "" {
+			log.Printf("[Subscription Middleware] Error: user_id not found in context")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		err := sm.DB.CheckAILimits(userID)
+		var limitErr *database.AILimitError
+		switch {
+		case errors.As(err, &limitErr):
+			log.Printf("[Subscription Middleware] User %s reached an AI limit: %v", userID, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(limitErr.ResetsAt).Seconds())+1))
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(models.AILimitErrorResponse{
+				Error:    "ai_limit_exceeded",
+				Message:  fmt.Sprintf("You have reached your plan's monthly AI %s limit", limitErr.Limit),
+				Limit:    limitErr.Limit,
+				Max:      limitErr.Max,
+				Used:     limitErr.Used,
+				ResetsAt: limitErr.ResetsAt,
+			})
+			return
+		case err != nil:
+			log.Printf("[Subscription Middleware] Error checking AI limits: %v", err)
+			if os.Getenv("ENV") != "production" {
+				log.Printf("[Subscription Middleware] DEVELOPMENT MODE: Bypassing AI limit check due to error")
+				next.ServeHTTP(w, r)
+				return
+			}
+			http.Error(w, "Error checking AI usage", http.StatusInternalServerError)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,146 @@
+package models
+
+import "time"
+
+// BrainstormConcept is one idea suggested by an AI provider. Concepts can
+// carry sub-branches that expand on them.
+type BrainstormConcept struct {
+	Title       string              `json:"title"`
+	Reason      string              `json:"reason"`
+	SubBranches []BrainstormConcept `json:"sub_branches,omitempty"`
+}
+
+// BrainstormSuggestions maps category names to the concepts suggested in
+// them, in the shape the board editor turns into nodes
+type BrainstormSuggestions map[string][]BrainstormConcept
+
+// BrainstormRequest is the payload for asking an AI provider for ideas
+// around a board node. Model defaults to the model selected in the user's
+// settings for the provider.
+type BrainstormRequest struct {
+	BoardID  string `json:"boardId"`
+	NodeID   string `json:"nodeId"`
+	Message  string `json:"message"`
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"`
+}
+
+// BrainstormResponse holds the validated suggestions returned by a provider
+type BrainstormResponse struct {
+	Provider    string                `json:"provider"`
+	Model       string                `json:"model"`
+	Suggestions BrainstormSuggestions `json:"suggestions"`
+}
+
+// BrainstormApplyRequest is the payload for adding suggestions to a board
+// below an existing node
+type BrainstormApplyRequest struct {
+	ParentID    string                `json:"parentId"`
+	Suggestions BrainstormSuggestions `json:"suggestions"`
+}
+
+// BrainstormApplyResponse lists the nodes and edges created for applied
+// suggestions, which the client deletes to undo the change
+type BrainstormApplyResponse struct {
+	BoardID string   `json:"boardId"`
+	Version int      `json:"version"`
+	NodeIDs []string `json:"nodeIds"`
+	EdgeIDs []string `json:"edgeIds"`
+}
+
+// Outcomes recorded for an AI request
+const (
+	AIOutcomeSuccess = "success"
+	// AIOutcomeError means the provider request failed
+	AIOutcomeError = "error"
+	// AIOutcomeInvalidResponse means the provider answered but the reply could not be used
+	AIOutcomeInvalidResponse = "invalid_response"
+	// AIOutcomeCancelled means the client disconnected before the reply was complete
+	AIOutcomeCancelled = "cancelled"
+)
+
+// Operations recorded for an AI request
+const (
+	AIOperationBrainstorm       = "brainstorm"
+	AIOperationBrainstormStream = "brainstorm_stream"
+	AIOperationListModels       = "list_models"
+)
+
+// AIUsage is one AI provider request made with a user's saved key
+type AIUsage struct {
+	ID               string    `json:"id"`
+	UserID           string    `json:"userId"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	Operation        string    `json:"operation"`
+	PromptTokens     int       `json:"promptTokens"`
+	CompletionTokens int       `json:"completionTokens"`
+	LatencyMs        int64     `json:"latencyMs"`
+	Outcome          string    `json:"outcome"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// AILimits are the monthly AI limits of a subscription plan. A zero limit
+// means unlimited. Tokens count both prompt and completion tokens.
+type AILimits struct {
+	MaxRequests int   `json:"maxRequests"`
+	MaxTokens   int64 `json:"maxTokens"`
+}
+
+// AIUsageTotals sums AI requests over a period. Failed requests count
+// towards Requests and are also counted in Errors.
+type AIUsageTotals struct {
+	Requests         int   `json:"requests"`
+	Errors           int   `json:"errors"`
+	PromptTokens     int64 `json:"promptTokens"`
+	CompletionTokens int64 `json:"completionTokens"`
+	TotalTokens      int64 `json:"totalTokens"`
+}
+
+// AIModelUsage is the usage of one provider model
+type AIModelUsage struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	AIUsageTotals
+	AvgLatencyMs int64 `json:"avgLatencyMs"`
+}
+
+// AIMonthlyUsage is a user's AI usage in a calendar month (UTC) with the
+// limits of their plan. Only brainstorm requests count towards the limits;
+// listing a provider's models is free and is left out.
+type AIMonthlyUsage struct {
+	Month       string         `json:"month"`
+	PeriodStart time.Time      `json:"periodStart"`
+	PeriodEnd   time.Time      `json:"periodEnd"`
+	Limits      AILimits       `json:"limits"`
+	Usage       AIUsageTotals  `json:"usage"`
+	ByModel     []AIModelUsage `json:"byModel"`
+}
+
+// AIUserUsage is one user's line in the admin AI usage report
+type AIUserUsage struct {
+	UserID string `json:"userId"`
+	Email  string `json:"email"`
+	AIUsageTotals
+}
+
+// AIUsageReport aggregates the AI usage of every user in a calendar month
+type AIUsageReport struct {
+	Month       string         `json:"month"`
+	PeriodStart time.Time      `json:"periodStart"`
+	PeriodEnd   time.Time      `json:"periodEnd"`
+	Totals      AIUsageTotals  `json:"totals"`
+	ByModel     []AIModelUsage `json:"byModel"`
+	Users       []AIUserUsage  `json:"users"`
+}
+
+// AILimitErrorResponse is returned with 429 Too Many Requests when a user has
+// reached a monthly AI limit. Limit is "requests" or "tokens".
+type AILimitErrorResponse struct {
+	Error    string    `json:"error"`
+	Message  string    `json:"message"`
+	Limit    string    `json:"limit"`
+	Max      int64     `json:"max"`
+	Used     int64     `json:"used"`
+	ResetsAt time.Time `json:"resetsAt"`
+}
@@ -27,6 +27,13 @@ func (bd *BoardData) Scan(value interface{}) error {
 	return json.Unmarshal(b, &bd)
 }
 
+// Roles a user can have on a board
+const (
+	BoardRoleOwner  = "owner"
+	BoardRoleEditor = "editor"
+	BoardRoleViewer = "viewer"
+)
+
 // Board represents a brainstorming board in the database
 type Board struct {
 	ID          string    `json:"id" db:"id"`
@@ -34,8 +41,17 @@ type Board struct {
 	UserID      string    `json:"userId" db:"user_id"`
 	Description string    `json:"description" db:"description"`
 	Data        BoardData `json:"data" db:"data"`
+	Version     int       `json:"version" db:"version"`
+	ForkedFrom  *string   `json:"forkedFrom,omitempty" db:"forked_from"`
 	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+	// Role is the requesting user's role on the board; it is not stored on the row
+	Role string `json:"role" db:"-"`
+}
+
+// CanEdit reports whether the requesting user may change the board's content
+func (b *Board) CanEdit() bool {
+	return b.Role == BoardRoleOwner || b.Role == BoardRoleEditor
 }
 
 // BoardResponse is the data sent back to the client when fetching a board
@@ -45,14 +61,110 @@ type BoardResponse struct {
 	Description string        `json:"description"`
 	Nodes       []interface{} `json:"nodes"`
 	Edges       []interface{} `json:"edges"`
+	Version     int           `json:"version"`
+	Role        string        `json:"role"`
+	ForkedFrom  *string       `json:"forkedFrom,omitempty"`
 	CreatedAt   time.Time     `json:"createdAt"`
 	UpdatedAt   time.Time     `json:"updatedAt"`
 }
 
+// BoardConflictResponse is returned with 412 Precondition Failed when an
+// update was based on an outdated version of the board
+type BoardConflictResponse struct {
+	Error   string        `json:"error"`
+	Message string        `json:"message"`
+	Board   BoardResponse `json:"board"`
+}
+
+// BoardFieldError describes a problem with one field of a board's nodes or
+// edges, e.g. {"field": "nodes[2].position.x", "message": "must be a number"}
+type BoardFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// BoardValidationErrorResponse is returned with 422 Unprocessable Entity when
+// board data fails validation
+type BoardValidationErrorResponse struct {
+	Error   string            `json:"error"`
+	Message string            `json:"message"`
+	Fields  []BoardFieldError `json:"fields"`
+}
+
+// BoardLimits are the board quotas of a subscription plan. A zero limit means unlimited.
+type BoardLimits struct {
+	MaxBoards        int   `json:"maxBoards"`
+	MaxNodesPerBoard int   `json:"maxNodesPerBoard"`
+	MaxStorageBytes  int64 `json:"maxStorageBytes"`
+}
+
+// BoardUsage is how much of their quota a user has used. Boards in the trash
+// take up storage but do not count towards the number of boards.
+type BoardUsage struct {
+	Boards       int   `json:"boards"`
+	StorageBytes int64 `json:"storageBytes"`
+}
+
+// BoardQuota pairs a user's plan limits with their current usage
+type BoardQuota struct {
+	Limits BoardLimits `json:"limits"`
+	Usage  BoardUsage  `json:"usage"`
+}
+
+// BoardQuotaErrorResponse is returned with 402 Payment Required when the
+// board limit is reached, or 413 Request Entity Too Large when a board would
+// exceed the node or storage limit. Quota is "boards", "nodes" or "storage".
+type BoardQuotaErrorResponse struct {
+	Error     string `json:"error"`
+	Message   string `json:"message"`
+	Quota     string `json:"quota"`
+	Limit     int64  `json:"limit"`
+	Requested int64  `json:"requested"`
+}
+
 // BoardCreateRequest is the payload for creating a new board
 type BoardCreateRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	// TemplateID optionally seeds the new board with a copy of a template's content
+	TemplateID string `json:"templateId,omitempty"`
+	// Data is the initial content of the board. It is set by the server,
+	// e.g. when importing a file, and an empty board is created when nil.
+	Data *BoardData `json:"-"`
+	// ForkedFrom is the board this one was duplicated from, if any
+	ForkedFrom *string `json:"-"`
+}
+
+// BoardTemplate is a reusable starting point for new boards. System
+// templates are published by admins and visible to everyone; other templates
+// are private to the user who saved them. Data is only set when a single
+// template is fetched.
+type BoardTemplate struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Category    string     `json:"category"`
+	System      bool       `json:"system"`
+	NodeCount   int        `json:"nodeCount"`
+	EdgeCount   int        `json:"edgeCount"`
+	Data        *BoardData `json:"data,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// BoardTemplateRequest is the payload for saving a template. Users save an
+// existing board through BoardID; admins may pass Data directly instead.
+type BoardTemplateRequest struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Category    string     `json:"category"`
+	BoardID     string     `json:"boardId,omitempty"`
+	Data        *BoardData `json:"data,omitempty"`
+}
+
+// BoardDuplicateRequest is the optional payload for duplicating a board
+type BoardDuplicateRequest struct {
+	Name string `json:"name"`
 }
 
 // BoardUpdateRequest is the payload for updating an existing board
@@ -68,8 +180,311 @@ type BoardListItem struct {
 	ID          string    `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
+	OwnerID     string    `json:"ownerId"`
+	Role        string    `json:"role"`
 	NodeCount   int       `json:"nodeCount"`
 	EdgeCount   int       `json:"edgeCount"`
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
+	Tags        []string  `json:"tags"`
+	// FolderID and FolderPath are only set for boards the user owns, since
+	// folders are private to each user
+	FolderID   *string `json:"folderId"`
+	FolderPath string  `json:"folderPath"`
+	// DeletedAt and PurgeAt are only set for boards in the trash
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	PurgeAt   *time.Time `json:"purgeAt,omitempty"`
+	// Metrics is the structure summary cached when the board was last
+	// saved; it is nil for boards not saved since metrics were introduced
+	Metrics *BoardMetrics `json:"metrics,omitempty"`
+}
+
+// BoardMetrics summarises the structure of a board's graph. Depth counts the
+// levels of the deepest hierarchy, BranchingFactor is the average number of
+// children of nodes that have any, leaves are nodes with a parent but no
+// children, orphans have no edges at all, and Cycles counts groups of nodes
+// that lead back to themselves.
+type BoardMetrics struct {
+	Depth           int     `json:"depth"`
+	BranchingFactor float64 `json:"branchingFactor"`
+	LeafCount       int     `json:"leafCount"`
+	OrphanCount     int     `json:"orphanCount"`
+	Components      int     `json:"components"`
+	Cycles          int     `json:"cycles"`
+}
+
+// BoardNodeDegree is a node with the number of edges touching it
+type BoardNodeDegree struct {
+	ID     string `json:"id"`
+	Label  string `json:"label"`
+	Degree int    `json:"degree"`
+}
+
+// BoardMetricsResponse is the full metrics report for a single board
+type BoardMetricsResponse struct {
+	BoardID       string            `json:"boardId"`
+	Version       int               `json:"version"`
+	NodeCount     int               `json:"nodeCount"`
+	EdgeCount     int               `json:"edgeCount"`
+	Metrics       BoardMetrics      `json:"metrics"`
+	Orphans       []string          `json:"orphans"`
+	MostConnected []BoardNodeDegree `json:"mostConnected"`
+}
+
+// BoardFolder is a user's folder for organising boards. Path is the names of
+// the folder and its ancestors joined with "/".
+type BoardFolder struct {
+	ID        string    `json:"id"`
+	ParentID  *string   `json:"parentId"`
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// BoardFolderRequest is the payload for creating, renaming or moving a folder.
+// A nil ParentID places the folder at the top level.
+type BoardFolderRequest struct {
+	Name     string  `json:"name"`
+	ParentID *string `json:"parentId"`
+}
+
+// BoardMoveRequest is the payload for filing a board in a folder. A nil
+// FolderID moves the board to the top level.
+type BoardMoveRequest struct {
+	FolderID *string `json:"folderId"`
+}
+
+// BoardTagsRequest is the payload for replacing the tags of a board
+type BoardTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// BoardTagCount is a tag together with the number of boards that carry it
+type BoardTagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// BoardSearchResult is a board matching a full-text search
+type BoardSearchResult struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	OwnerID     string    `json:"ownerId"`
+	Role        string    `json:"role"`
+	Rank        float64   `json:"rank"`
+	NodeIDs     []string  `json:"nodeIds"`
+	Snippet     string    `json:"snippet"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// BoardRevision is a snapshot of a board's content taken before an update
+type BoardRevision struct {
+	ID          string    `json:"id" db:"id"`
+	BoardID     string    `json:"boardId" db:"board_id"`
+	UserID      string    `json:"userId" db:"user_id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	Data        BoardData `json:"data" db:"data"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+}
+
+// BoardRevisionListItem represents a summary of a board revision for listing
+type BoardRevisionListItem struct {
+	ID        string    `json:"id"`
+	BoardID   string    `json:"boardId"`
+	UserID    string    `json:"userId"`
+	Name      string    `json:"name"`
+	NodeCount int       `json:"nodeCount"`
+	EdgeCount int       `json:"edgeCount"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BoardRevisionResponse is the data sent back to the client when fetching a revision
+type BoardRevisionResponse struct {
+	ID          string        `json:"id"`
+	BoardID     string        `json:"boardId"`
+	UserID      string        `json:"userId"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Nodes       []interface{} `json:"nodes"`
+	Edges       []interface{} `json:"edges"`
+	CreatedAt   time.Time     `json:"createdAt"`
+}
+
+// BoardElementPatch describes changes to either the nodes or the edges of a board.
+// Elements are matched by their "id" field.
+type BoardElementPatch struct {
+	// Add appends new elements; their IDs must not already exist
+	Add []json.RawMessage `json:"add,omitempty"`
+	// Update merges each object into the existing element with the same ID
+	// using JSON Merge Patch semantics (RFC 7386)
+	Update []json.RawMessage `json:"update,omitempty"`
+	// Remove deletes the elements with the given IDs; unknown IDs are ignored
+	Remove []string `json:"remove,omitempty"`
+}
+
+// BoardPatchRequest is the payload for incrementally updating a board
+type BoardPatchRequest struct {
+	Nodes BoardElementPatch `json:"nodes"`
+	Edges BoardElementPatch `json:"edges"`
+}
+
+// BoardPatchResponse summarizes a board after a patch was applied
+type BoardPatchResponse struct {
+	ID        string    `json:"id"`
+	Version   int       `json:"version"`
+	NodeCount int       `json:"nodeCount"`
+	EdgeCount int       `json:"edgeCount"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// BoardNodeCreateRequest is the payload for adding a single node. When
+// ParentID is set, an edge from the parent to the new node is created too.
+type BoardNodeCreateRequest struct {
+	Node     json.RawMessage `json:"node"`
+	ParentID string          `json:"parentId,omitempty"`
+}
+
+// BoardNodeResponse returns a single node with the board version it belongs to
+type BoardNodeResponse struct {
+	BoardID string          `json:"boardId"`
+	Version int             `json:"version"`
+	Node    json.RawMessage `json:"node"`
+	// Edge is the edge created from the parent node, if any
+	Edge json.RawMessage `json:"edge,omitempty"`
+}
+
+// BoardEdgeResponse returns a single edge with the board version it belongs to
+type BoardEdgeResponse struct {
+	BoardID string          `json:"boardId"`
+	Version int             `json:"version"`
+	Edge    json.RawMessage `json:"edge"`
+}
+
+// BoardElementDeleteResponse lists the nodes and edges removed by a delete
+type BoardElementDeleteResponse struct {
+	BoardID      string   `json:"boardId"`
+	Version      int      `json:"version"`
+	RemovedNodes []string `json:"removedNodes"`
+	RemovedEdges []string `json:"removedEdges"`
+}
+
+// BoardNodePosition is the top-left corner of a node on the canvas
+type BoardNodePosition struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// BoardLayoutResponse returns the node positions computed by a layout
+// algorithm. On a dry run nothing is saved and Version is the board's
+// current version.
+type BoardLayoutResponse struct {
+	BoardID   string                       `json:"boardId"`
+	Version   int                          `json:"version"`
+	Algorithm string                       `json:"algorithm"`
+	DryRun    bool                         `json:"dryRun"`
+	Positions map[string]BoardNodePosition `json:"positions"`
+}
+
+// BoardMember is a user a board has been shared with
+type BoardMember struct {
+	BoardID   string    `json:"boardId"`
+	UserID    string    `json:"userId"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	InvitedBy string    `json:"invitedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// BoardInviteRequest is the payload for sharing a board with a user by email
+type BoardInviteRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// BoardInviteResponse acknowledges an invite. It is the same whether or not
+// the email belongs to an account, so invites cannot be used to find users.
+type BoardInviteResponse struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// BoardMemberRoleRequest is the payload for changing a member's role
+type BoardMemberRoleRequest struct {
+	UserID string `json:"userId"`
+	Role   string `json:"role"`
+}
+
+// BoardShareLink is a revocable public read-only link to a board
+type BoardShareLink struct {
+	ID           string     `json:"id"`
+	BoardID      string     `json:"boardId"`
+	Token        string     `json:"token"`
+	URL          string     `json:"url,omitempty"`
+	PasswordHash string     `json:"-"`
+	HasPassword  bool       `json:"hasPassword"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+	CreatedBy    string     `json:"createdBy"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// BoardShareLinkCreateRequest is the payload for creating a share link
+type BoardShareLinkCreateRequest struct {
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Password  string     `json:"password,omitempty"`
+}
+
+// BoardComment is a comment on a board node. Top-level comments start a
+// thread; replies point at the thread through ParentID and only threads can
+// be resolved. Replies are only filled in when listing threads.
+type BoardComment struct {
+	ID         string                `json:"id"`
+	BoardID    string                `json:"boardId"`
+	NodeID     string                `json:"nodeId"`
+	ParentID   *string               `json:"parentId,omitempty"`
+	UserID     string                `json:"userId"`
+	AuthorName string                `json:"authorName"`
+	Body       string                `json:"body"`
+	Mentions   []BoardCommentMention `json:"mentions"`
+	Resolved   bool                  `json:"resolved"`
+	ResolvedAt *time.Time            `json:"resolvedAt,omitempty"`
+	ResolvedBy *string               `json:"resolvedBy,omitempty"`
+	CreatedAt  time.Time             `json:"createdAt"`
+	UpdatedAt  time.Time             `json:"updatedAt"`
+	Replies    []BoardComment        `json:"replies,omitempty"`
+}
+
+// BoardCommentMention is a user @mentioned in a comment. The email is only
+// used to notify them and is not sent to clients.
+type BoardCommentMention struct {
+	UserID string `json:"userId"`
+	Name   string `json:"name"`
+	Email  string `json:"-"`
+}
+
+// BoardCommentCreateRequest is the payload for commenting on a node. A reply
+// sets ParentID and is placed on the thread's node, so NodeID may be omitted.
+// Mentions are the IDs of users with access to the board.
+type BoardCommentCreateRequest struct {
+	NodeID   string   `json:"nodeId"`
+	ParentID string   `json:"parentId,omitempty"`
+	Body     string   `json:"body"`
+	Mentions []string `json:"mentions,omitempty"`
+}
+
+// BoardCommentUpdateRequest is the payload for editing a comment; it
+// replaces the body and the mentioned users
+type BoardCommentUpdateRequest struct {
+	Body     string   `json:"body"`
+	Mentions []string `json:"mentions,omitempty"`
+}
+
+// BoardCommentResolveRequest is the payload for resolving or reopening a thread
+type BoardCommentResolveRequest struct {
+	Resolved bool `json:"resolved"`
 }
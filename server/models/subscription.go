@@ -20,4 +20,6 @@ type Subscription struct {
 	TrialEndsAt    *time.Time `json:"trial_ends_at,omitempty"`
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
+	// BoardQuota is the plan's board limits and the user's usage, filled in by the API
+	BoardQuota *BoardQuota `json:"board_quota,omitempty"`
 }
@@ -0,0 +1,185 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// AnthropicBaseURL is the API root of Anthropic
+	AnthropicBaseURL = "https://api.anthropic.com/v1"
+	// anthropicVersion is the Messages API version sent with every request
+	anthropicVersion = "2023-06-01"
+)
+
+// Anthropic is an adapter for the Anthropic Messages API
+type Anthropic struct {
+	name    string
+	baseURL string
+	client  *http.Client
+}
+
+// NewAnthropic creates an adapter for the Anthropic API, which is normally
+// rooted at AnthropicBaseURL
+func NewAnthropic(baseURL string, client *http.Client) *Anthropic {
+	return &Anthropic{name: ProviderClaude, baseURL: strings.TrimRight(baseURL, "/"), client: client}
+}
+
+// Name returns the name the adapter is registered under
+func (p *Anthropic) Name() string {
+	return p.name
+}
+
+// RequiresKey reports that the Anthropic API always needs a key
+func (p *Anthropic) RequiresKey() bool {
+	return true
+}
+
+// anthropicUsage is the token usage block of a message
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Complete sends a Messages API request
+func (p *Anthropic) Complete(ctx context.Context, apiKey string, req Request) (*Completion, error) {
+	var response struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage anthropicUsage `json:"usage"`
+	}
+	err := sendJSON(ctx, p.client, p.name, http.MethodPost, p.baseURL+"/messages",
+		p.headers(apiKey), p.body(req, false), &response)
+	if err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	for _, block := range response.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if strings.TrimSpace(text.String()) == "" {
+		return nil, ErrEmptyCompletion
+	}
+
+	return &Completion{
+		Text: text.String(),
+		Usage: Usage{
+			PromptTokens:     response.Usage.InputTokens,
+			CompletionTokens: response.Usage.OutputTokens,
+		},
+	}, nil
+}
+
+// Stream sends a Messages API request with streaming enabled
+func (p *Anthropic) Stream(ctx context.Context, apiKey string, req Request, onDelta func(text string) error) (*Completion, error) {
+	resp, err := send(ctx, p.client, p.name, http.MethodPost, p.baseURL+"/messages",
+		p.headers(apiKey), p.body(req, true))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	completion := &Completion{}
+	var text strings.Builder
+	err = readEvents(resp.Body, func(event, data string) error {
+		var payload struct {
+			Message struct {
+				Usage anthropicUsage `json:"usage"`
+			} `json:"message"`
+			Delta struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"delta"`
+			Usage anthropicUsage `json:"usage"`
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &payload); err != nil {
+			return fmt.Errorf("decoding %s stream: %w", p.name, err)
+		}
+
+		switch event {
+		case "message_start":
+			completion.Usage.PromptTokens = payload.Message.Usage.InputTokens
+		case "message_delta":
+			completion.Usage.CompletionTokens = payload.Usage.OutputTokens
+		case "content_block_delta":
+			if payload.Delta.Type == "text_delta" && payload.Delta.Text != "" {
+				text.WriteString(payload.Delta.Text)
+				return onDelta(payload.Delta.Text)
+			}
+		case "error":
+			return fmt.Errorf("%s stream failed: %s: %s", p.name, payload.Error.Type, payload.Error.Message)
+		}
+		return nil
+	})
+	completion.Text = text.String()
+	if err != nil {
+		return completion, err
+	}
+
+	if strings.TrimSpace(completion.Text) == "" {
+		return nil, ErrEmptyCompletion
+	}
+	return completion, nil
+}
+
+// ListModels lists the models available to the key
+func (p *Anthropic) ListModels(ctx context.Context, apiKey string) ([]string, error) {
+	var response struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := sendJSON(ctx, p.client, p.name, http.MethodGet, p.baseURL+"/models", p.headers(apiKey), nil, &response); err != nil {
+		return nil, err
+	}
+
+	models := make([]string, 0, len(response.Data))
+	for _, model := range response.Data {
+		models = append(models, model.ID)
+	}
+	return models, nil
+}
+
+// ValidateKey checks the key by listing models
+func (p *Anthropic) ValidateKey(ctx context.Context, apiKey string) error {
+	return validateKey(ctx, p, apiKey)
+}
+
+// headers returns the authentication and version headers
+func (p *Anthropic) headers(apiKey string) map[string]string {
+	return map[string]string{
+		"x-api-key":         apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+}
+
+// body builds a Messages API request body
+func (p *Anthropic) body(req Request, stream bool) map[string]interface{} {
+	req = withDefaults(req)
+
+	body := map[string]interface{}{
+		"model":       req.Model,
+		"messages":    req.Messages,
+		"max_tokens":  req.MaxTokens,
+		"temperature": req.Temperature,
+	}
+	if req.System != "" {
+		body["system"] = req.System
+	}
+	if stream {
+		body["stream"] = true
+	}
+	return body
+}
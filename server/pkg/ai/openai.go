@@ -0,0 +1,195 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// OpenAIBaseURL is the API root of OpenAI
+const OpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAI is an adapter for the OpenAI chat completions API and the many
+// services that mirror it, such as kluster.ai or a local Ollama server
+type OpenAI struct {
+	name    string
+	baseURL string
+	client  *http.Client
+	// streamUsage asks for token usage in streamed responses; compatible
+	// services do not all accept the option
+	streamUsage bool
+	// keyless is set for services that accept requests without a key
+	keyless bool
+}
+
+// NewOpenAI creates an adapter for OpenAI itself, whose API is normally
+// rooted at OpenAIBaseURL
+func NewOpenAI(baseURL string, client *http.Client) *OpenAI {
+	return &OpenAI{name: ProviderOpenAI, baseURL: strings.TrimRight(baseURL, "/"), client: client, streamUsage: true}
+}
+
+// NewOpenAICompatible creates an adapter registered as name for a service
+// with an OpenAI-compatible API rooted at baseURL, such as
+// https://api.kluster.ai/v1, that needs an API key
+func NewOpenAICompatible(name, baseURL string, client *http.Client) *OpenAI {
+	return &OpenAI{name: name, baseURL: strings.TrimRight(baseURL, "/"), client: client}
+}
+
+// NewKeylessOpenAICompatible creates an adapter like NewOpenAICompatible for
+// a service that needs no API key, such as a local Ollama server at
+// http://localhost:11434/v1. A saved key is still sent if there is one.
+func NewKeylessOpenAICompatible(name, baseURL string, client *http.Client) *OpenAI {
+	return &OpenAI{name: name, baseURL: strings.TrimRight(baseURL, "/"), client: client, keyless: true}
+}
+
+// Name returns the name the adapter is registered under
+func (p *OpenAI) Name() string {
+	return p.name
+}
+
+// RequiresKey reports whether the service needs an API key
+func (p *OpenAI) RequiresKey() bool {
+	return !p.keyless
+}
+
+// openAIUsage is the token usage block of a chat completion
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func (u *openAIUsage) usage() Usage {
+	if u == nil {
+		return Usage{}
+	}
+	return Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens}
+}
+
+// Complete sends a chat completion request
+func (p *OpenAI) Complete(ctx context.Context, apiKey string, req Request) (*Completion, error) {
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage *openAIUsage `json:"usage"`
+	}
+	err := sendJSON(ctx, p.client, p.name, http.MethodPost, p.baseURL+"/chat/completions",
+		p.headers(apiKey), p.body(req, false), &response)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response.Choices) == 0 || strings.TrimSpace(response.Choices[0].Message.Content) == "" {
+		return nil, ErrEmptyCompletion
+	}
+	return &Completion{Text: response.Choices[0].Message.Content, Usage: response.Usage.usage()}, nil
+}
+
+// Stream sends a chat completion request with streaming enabled
+func (p *OpenAI) Stream(ctx context.Context, apiKey string, req Request, onDelta func(text string) error) (*Completion, error) {
+	resp, err := send(ctx, p.client, p.name, http.MethodPost, p.baseURL+"/chat/completions",
+		p.headers(apiKey), p.body(req, true))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	completion := &Completion{}
+	var text strings.Builder
+	err = readEvents(resp.Body, func(event, data string) error {
+		if data == "[DONE]" {
+			return nil
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("decoding %s stream: %w", p.name, err)
+		}
+
+		if chunk.Usage != nil {
+			completion.Usage = chunk.Usage.usage()
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			return nil
+		}
+		text.WriteString(chunk.Choices[0].Delta.Content)
+		return onDelta(chunk.Choices[0].Delta.Content)
+	})
+	completion.Text = text.String()
+	if err != nil {
+		return completion, err
+	}
+
+	if strings.TrimSpace(completion.Text) == "" {
+		return nil, ErrEmptyCompletion
+	}
+	return completion, nil
+}
+
+// ListModels lists the models available to the key
+func (p *OpenAI) ListModels(ctx context.Context, apiKey string) ([]string, error) {
+	var response struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := sendJSON(ctx, p.client, p.name, http.MethodGet, p.baseURL+"/models", p.headers(apiKey), nil, &response); err != nil {
+		return nil, err
+	}
+
+	models := make([]string, 0, len(response.Data))
+	for _, model := range response.Data {
+		models = append(models, model.ID)
+	}
+	return models, nil
+}
+
+// ValidateKey checks the key by listing models
+func (p *OpenAI) ValidateKey(ctx context.Context, apiKey string) error {
+	return validateKey(ctx, p, apiKey)
+}
+
+// headers returns the authentication headers, omitted when there is no key
+func (p *OpenAI) headers(apiKey string) map[string]string {
+	if apiKey == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + apiKey}
+}
+
+// body builds a chat completion request body, sending the system prompt as
+// the first message
+func (p *OpenAI) body(req Request, stream bool) map[string]interface{} {
+	req = withDefaults(req)
+
+	messages := make([]Message, 0, len(req.Messages)+1)
+	if req.System != "" {
+		messages = append(messages, Message{Role: "system", Content: req.System})
+	}
+	messages = append(messages, req.Messages...)
+
+	body := map[string]interface{}{
+		"model":       req.Model,
+		"messages":    messages,
+		"max_tokens":  req.MaxTokens,
+		"temperature": req.Temperature,
+	}
+	if stream {
+		body["stream"] = true
+		if p.streamUsage {
+			body["stream_options"] = map[string]bool{"include_usage": true}
+		}
+	}
+	return body
+}
@@ -0,0 +1,82 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"saas-server/pkg/boardgraph"
+)
+
+// maxContextContent is how many characters of a node's content are included
+// in a prompt, so large notes do not crowd out the request
+const maxContextContent = 500
+
+// BrainstormSystemPrompt tells the model to answer with categories of
+// concepts in the JSON shape ParseSuggestions accepts
+const BrainstormSystemPrompt = `You are an expert mind-mapping and brainstorming assistant for creative thinking.
+
+You will receive the path from the root of a mind map down to the node the user is working on, the node's details and existing children, and the user's request.
+
+Respond with a JSON object whose keys are category names and whose values are arrays of concepts:
+
+{
+  "Category 1": [
+    {
+      "title": "Concept 1A",
+      "reason": "Why this idea is relevant or important",
+      "sub_branches": [
+        {"title": "Sub-concept 1", "reason": "Explanation for sub-concept"}
+      ]
+    },
+    {"title": "Concept 1B", "reason": "Justification for this idea's inclusion"}
+  ]
+}
+
+GUIDELINES:
+- Create 3-5 categories that naturally group related ideas
+- Provide 3-6 specific concepts for each category
+- Keep concept titles concise (under 8 words is ideal)
+- Include a brief reason for each concept (1-2 sentences)
+- Add sub_branches only where they add significant value
+- Do not repeat ideas that already exist as children of the node
+- Return ONLY the JSON object with no additional text or formatting`
+
+// BrainstormMessages builds the user message for a brainstorm around node,
+// describing its ancestors from the root down, its content and its children
+func BrainstormMessages(boardName string, graph *boardgraph.Graph, node *boardgraph.Node, request string) []Message {
+	var prompt strings.Builder
+
+	fmt.Fprintf(&prompt, "Board title: %s\n\n", boardName)
+
+	prompt.WriteString("Path from the root to the current node:\n")
+	ancestors := graph.Ancestors(node.ID)
+	for depth, ancestor := range ancestors {
+		fmt.Fprintf(&prompt, "%s- %s\n", strings.Repeat("  ", depth), ancestor.Title())
+	}
+	fmt.Fprintf(&prompt, "%s- %s (current node)\n\n", strings.Repeat("  ", len(ancestors)), node.Title())
+
+	if content := truncate(node.Content, maxContextContent); content != "" && content != node.Title() {
+		fmt.Fprintf(&prompt, "Current node details:\n%s\n\n", content)
+	}
+
+	if children := graph.Children(node.ID); len(children) > 0 {
+		prompt.WriteString("Existing children of the current node:\n")
+		for _, child := range children {
+			fmt.Fprintf(&prompt, "- %s\n", child.Title())
+		}
+		prompt.WriteString("\n")
+	}
+
+	fmt.Fprintf(&prompt, "Request: %s", request)
+
+	return []Message{{Role: "user", Content: prompt.String()}}
+}
+
+// truncate shortens s to at most limit characters, marking the cut with an ellipsis
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	return string([]rune(s)[:limit]) + "…"
+}
@@ -0,0 +1,114 @@
+// Package ai talks to the AI providers users store API keys for, so requests
+// can be made server-side without handing the keys to the browser. Each
+// provider is an adapter behind the Provider interface, looked up by the
+// name its key is saved under in the user's AI settings.
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	// DefaultMaxTokens is the completion length requested when none is given
+	DefaultMaxTokens = 1500
+	// DefaultTemperature is the sampling temperature used for brainstorming
+	DefaultTemperature = 0.7
+)
+
+// ErrUnknownProvider is returned for a provider name that is not registered
+var ErrUnknownProvider = errors.New("unknown AI provider")
+
+// ErrEmptyCompletion is returned when a provider answers without any text
+var ErrEmptyCompletion = errors.New("AI provider returned an empty completion")
+
+// ErrInvalidKey is returned by ValidateKey when the provider rejects the key
+var ErrInvalidKey = errors.New("AI provider rejected the API key")
+
+// Provider is an AI provider that can be called with a user's API key
+type Provider interface {
+	// Name is the key the provider's settings are saved under
+	Name() string
+	// RequiresKey reports whether requests need an API key. Providers that
+	// do not, such as a local Ollama server, are called without one unless
+	// the user saved a key anyway.
+	RequiresKey() bool
+	// Complete sends a request and waits for the whole reply
+	Complete(ctx context.Context, apiKey string, req Request) (*Completion, error)
+	// Stream sends a request and calls onDelta with each piece of text as it
+	// arrives. Returning an error from onDelta stops the stream. The returned
+	// completion holds the full text and token usage. When the stream fails
+	// after the provider accepted the request, the completion holds the text
+	// and usage received so far and is returned with the error.
+	Stream(ctx context.Context, apiKey string, req Request, onDelta func(text string) error) (*Completion, error)
+	// ListModels returns the IDs of the models the key can use
+	ListModels(ctx context.Context, apiKey string) ([]string, error)
+	// ValidateKey checks that the provider accepts the key, returning
+	// ErrInvalidKey if it does not
+	ValidateKey(ctx context.Context, apiKey string) error
+}
+
+// Message is a single chat message sent to a provider
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request is a chat completion request
+type Request struct {
+	Model       string
+	System      string
+	Messages    []Message
+	MaxTokens   int
+	Temperature float64
+}
+
+// Usage is the number of tokens a request used, as reported by the provider.
+// Providers that do not report usage leave it zero.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Completion is a provider's reply to a request
+type Completion struct {
+	Text  string
+	Usage Usage
+}
+
+// ProviderError is returned when a provider responds with an error status
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s returned %d: %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// Unauthorized reports whether the provider rejected the API key
+func (e *ProviderError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// withDefaults fills in the fields of req that were left zero
+func withDefaults(req Request) Request {
+	if req.MaxTokens == 0 {
+		req.MaxTokens = DefaultMaxTokens
+	}
+	return req
+}
+
+// validateKey implements ValidateKey by listing models, which every
+// provider allows with any valid key and without cost
+func validateKey(ctx context.Context, p Provider, apiKey string) error {
+	_, err := p.ListModels(ctx, apiKey)
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) && providerErr.Unauthorized() {
+		return fmt.Errorf("%w: %s", ErrInvalidKey, p.Name())
+	}
+	return err
+}
@@ -0,0 +1,392 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"saas-server/models"
+	"saas-server/pkg/boardgraph"
+)
+
+const brainstormReply = "```json\n" + `{
+  "Research": [
+    {"title": " Competitor analysis ", "reason": "Know the market.",
+     "sub_branches": [{"title": "Pricing", "reason": "Compare plans."}]}
+  ],
+  "Features": [{"title": "Social login", "reason": "Less friction."}]
+}` + "\n```"
+
+// standIn serves canned provider replies and records the last request
+type standIn struct {
+	server  *httptest.Server
+	method  string
+	path    string
+	headers http.Header
+	body    map[string]interface{}
+}
+
+// newStandIn replies with reply encoded as JSON, or written as is when it is
+// a string such as a Server-Sent Events stream
+func newStandIn(t *testing.T, status int, reply interface{}) *standIn {
+	s := &standIn{}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.method = r.Method
+		s.path = r.URL.Path
+		s.headers = r.Header.Clone()
+		s.body = nil
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(&s.body); err != nil {
+				t.Errorf("decoding request body: %v", err)
+			}
+		}
+
+		if stream, ok := reply.(string); ok {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(status)
+			io.WriteString(w, stream)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(reply)
+	}))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func brainstormRequest() Request {
+	return Request{
+		Model:    "test-model",
+		System:   BrainstormSystemPrompt,
+		Messages: []Message{{Role: "user", Content: "Request: ideas"}},
+	}
+}
+
+// collect returns an onDelta callback that appends to deltas
+func collect(deltas *[]string) func(string) error {
+	return func(text string) error {
+		*deltas = append(*deltas, text)
+		return nil
+	}
+}
+
+func TestOpenAICompatibleComplete(t *testing.T) {
+	for name, newProvider := range map[string]func(baseURL string) Provider{
+		"openai":  func(baseURL string) Provider { return NewOpenAI(baseURL, HTTPClient) },
+		"kluster": func(baseURL string) Provider { return NewOpenAICompatible(ProviderKluster, baseURL, HTTPClient) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			stub := newStandIn(t, http.StatusOK, map[string]interface{}{
+				"choices": []map[string]interface{}{
+					{"message": map[string]string{"role": "assistant", "content": brainstormReply}},
+				},
+				"usage": map[string]int{"prompt_tokens": 120, "completion_tokens": 80},
+			})
+
+			completion, err := newProvider(stub.server.URL).Complete(context.Background(), "sk-test-key", brainstormRequest())
+			if err != nil {
+				t.Fatalf("Complete: %v", err)
+			}
+			if completion.Text != brainstormReply {
+				t.Errorf("text = %q, want the stand-in reply", completion.Text)
+			}
+			if completion.Usage != (Usage{PromptTokens: 120, CompletionTokens: 80}) {
+				t.Errorf("usage = %+v", completion.Usage)
+			}
+
+			if stub.path != "/chat/completions" {
+				t.Errorf("path = %q, want /chat/completions", stub.path)
+			}
+			if got := stub.headers.Get("Authorization"); got != "Bearer sk-test-key" {
+				t.Errorf("Authorization = %q", got)
+			}
+			messages := stub.body["messages"].([]interface{})
+			if len(messages) != 2 || messages[0].(map[string]interface{})["role"] != "system" {
+				t.Errorf("messages = %v, want the system prompt followed by the user message", messages)
+			}
+		})
+	}
+}
+
+func TestOpenAICompatibleWithoutKey(t *testing.T) {
+	stub := newStandIn(t, http.StatusOK, map[string]interface{}{
+		"data": []map[string]string{{"id": "llama3"}, {"id": "mistral"}},
+	})
+
+	provider := NewKeylessOpenAICompatible(ProviderOllama, stub.server.URL+"/", HTTPClient)
+	if provider.RequiresKey() {
+		t.Error("keyless provider requires a key")
+	}
+	if !NewOpenAICompatible(ProviderKluster, stub.server.URL, HTTPClient).RequiresKey() {
+		t.Error("compatible provider does not require a key")
+	}
+
+	models, err := provider.ListModels(context.Background(), "")
+	if err != nil {
+		t.Fatalf("ListModels: %v", err)
+	}
+	if strings.Join(models, ",") != "llama3,mistral" {
+		t.Errorf("models = %v", models)
+	}
+	if stub.method != http.MethodGet || stub.path != "/models" {
+		t.Errorf("request = %s %s, want GET /models", stub.method, stub.path)
+	}
+	if stub.headers.Get("Authorization") != "" {
+		t.Error("Authorization sent without a key")
+	}
+}
+
+func TestOpenAIStream(t *testing.T) {
+	stub := newStandIn(t, http.StatusOK, ""+
+		"data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n"+
+		": keep-alive\n\n"+
+		"data: {\"choices\":[{\"delta\":{\"content\":\"{\\\"Ideas\\\": \"}}]}\n\n"+
+		"data: {\"choices\":[{\"delta\":{\"content\":\"[]}\"}}]}\n\n"+
+		"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":4}}\n\n"+
+		"data: [DONE]\n\n")
+
+	var deltas []string
+	completion, err := NewOpenAI(stub.server.URL, HTTPClient).Stream(context.Background(), "sk-test-key", brainstormRequest(), collect(&deltas))
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	if strings.Join(deltas, "|") != `{"Ideas": |[]}` {
+		t.Errorf("deltas = %q", deltas)
+	}
+	if completion.Text != `{"Ideas": []}` {
+		t.Errorf("text = %q", completion.Text)
+	}
+	if completion.Usage != (Usage{PromptTokens: 10, CompletionTokens: 4}) {
+		t.Errorf("usage = %+v", completion.Usage)
+	}
+	if stub.body["stream"] != true || stub.body["stream_options"] == nil {
+		t.Errorf("body = %v, want streaming with usage", stub.body)
+	}
+}
+
+func TestAnthropicComplete(t *testing.T) {
+	stub := newStandIn(t, http.StatusOK, map[string]interface{}{
+		"content": []map[string]string{{"type": "text", "text": brainstormReply}},
+		"usage":   map[string]int{"input_tokens": 90, "output_tokens": 60},
+	})
+
+	completion, err := NewAnthropic(stub.server.URL, HTTPClient).Complete(context.Background(), "sk-ant-test", brainstormRequest())
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if completion.Text != brainstormReply {
+		t.Errorf("text = %q, want the stand-in reply", completion.Text)
+	}
+	if completion.Usage != (Usage{PromptTokens: 90, CompletionTokens: 60}) {
+		t.Errorf("usage = %+v", completion.Usage)
+	}
+
+	if stub.path != "/messages" {
+		t.Errorf("path = %q, want /messages", stub.path)
+	}
+	if got := stub.headers.Get("x-api-key"); got != "sk-ant-test" {
+		t.Errorf("x-api-key = %q", got)
+	}
+	if stub.headers.Get("Authorization") != "" {
+		t.Error("Anthropic requests must not send an Authorization header")
+	}
+	if stub.body["system"] != BrainstormSystemPrompt {
+		t.Error("system prompt not sent in the system field")
+	}
+}
+
+func TestAnthropicStream(t *testing.T) {
+	stub := newStandIn(t, http.StatusOK, ""+
+		"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":25}}}\n\n"+
+		"event: ping\ndata: {\"type\":\"ping\"}\n\n"+
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Hello\"}}\n\n"+
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\" world\"}}\n\n"+
+		"event: message_delta\ndata: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":3}}\n\n"+
+		"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+
+	var deltas []string
+	completion, err := NewAnthropic(stub.server.URL, HTTPClient).Stream(context.Background(), "sk-ant-test", brainstormRequest(), collect(&deltas))
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	if strings.Join(deltas, "|") != "Hello| world" {
+		t.Errorf("deltas = %q", deltas)
+	}
+	if completion.Usage != (Usage{PromptTokens: 25, CompletionTokens: 3}) {
+		t.Errorf("usage = %+v", completion.Usage)
+	}
+}
+
+func TestStreamStopsWhenCallbackFails(t *testing.T) {
+	stub := newStandIn(t, http.StatusOK, ""+
+		"data: {\"choices\":[{\"delta\":{\"content\":\"one\"}}]}\n\n"+
+		"data: {\"choices\":[{\"delta\":{\"content\":\"two\"}}]}\n\n")
+
+	stop := errors.New("client went away")
+	calls := 0
+	completion, err := NewOpenAI(stub.server.URL, HTTPClient).Stream(context.Background(), "sk-test-key", brainstormRequest(), func(string) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("err = %v after %d calls, want the callback error after 1", err, calls)
+	}
+	if completion == nil || completion.Text != "one" {
+		t.Errorf("completion = %+v, want the text received before the error", completion)
+	}
+}
+
+func TestMeteredUsage(t *testing.T) {
+	req := Request{System: "12345678", Messages: []Message{{Role: "user", Content: "1234"}}}
+
+	if usage := MeteredUsage(req, nil); usage != (Usage{}) {
+		t.Errorf("usage without a completion = %+v, want none", usage)
+	}
+
+	reported := &Completion{Text: "ignored", Usage: Usage{PromptTokens: 40, CompletionTokens: 7}}
+	if usage := MeteredUsage(req, reported); usage != reported.Usage {
+		t.Errorf("usage = %+v, want the reported %+v", usage, reported.Usage)
+	}
+
+	// A stream cut short before any usage was reported
+	partial := &Completion{Text: "123456789"}
+	if usage := MeteredUsage(req, partial); usage != (Usage{PromptTokens: 3, CompletionTokens: 3}) {
+		t.Errorf("estimated usage = %+v", usage)
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	rejected := newStandIn(t, http.StatusUnauthorized, map[string]interface{}{
+		"error": map[string]string{"message": "Incorrect API key provided"},
+	})
+	if err := NewOpenAI(rejected.server.URL, HTTPClient).ValidateKey(context.Background(), "sk-wrong-key"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("err = %v, want ErrInvalidKey", err)
+	}
+
+	accepted := newStandIn(t, http.StatusOK, map[string]interface{}{
+		"data": []map[string]string{{"id": "claude-sonnet"}},
+	})
+	if err := NewAnthropic(accepted.server.URL, HTTPClient).ValidateKey(context.Background(), "sk-ant-test"); err != nil {
+		t.Errorf("ValidateKey: %v", err)
+	}
+	if got := accepted.headers.Get("anthropic-version"); got == "" {
+		t.Error("anthropic-version header not sent")
+	}
+}
+
+func TestCompleteProviderError(t *testing.T) {
+	stub := newStandIn(t, http.StatusTooManyRequests, map[string]interface{}{
+		"error": map[string]string{"message": "Rate limit reached"},
+	})
+
+	_, err := NewOpenAI(stub.server.URL, HTTPClient).Complete(context.Background(), "sk-test-key", brainstormRequest())
+
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) {
+		t.Fatalf("err = %v, want a *ProviderError", err)
+	}
+	if providerErr.StatusCode != http.StatusTooManyRequests || providerErr.Unauthorized() {
+		t.Errorf("StatusCode = %d", providerErr.StatusCode)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	for _, name := range []string{ProviderOpenAI, ProviderClaude, ProviderKluster} {
+		provider, err := Lookup(name)
+		if err != nil {
+			t.Errorf("Lookup(%q): %v", name, err)
+			continue
+		}
+		if provider.Name() != name {
+			t.Errorf("Lookup(%q) returned %q", name, provider.Name())
+		}
+	}
+
+	if _, err := Lookup("gemini"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("err = %v, want ErrUnknownProvider", err)
+	}
+}
+
+func TestParseSuggestions(t *testing.T) {
+	suggestions, err := ParseSuggestions(brainstormReply)
+	if err != nil {
+		t.Fatalf("ParseSuggestions: %v", err)
+	}
+
+	research := suggestions["Research"]
+	if len(suggestions) != 2 || len(research) != 1 {
+		t.Fatalf("suggestions = %+v, want two categories", suggestions)
+	}
+	if research[0].Title != "Competitor analysis" {
+		t.Errorf("title = %q, want it trimmed", research[0].Title)
+	}
+	if len(research[0].SubBranches) != 1 || research[0].SubBranches[0].Title != "Pricing" {
+		t.Errorf("sub_branches = %+v", research[0].SubBranches)
+	}
+}
+
+func TestParseSuggestionsRejectsInvalidShapes(t *testing.T) {
+	for name, raw := range map[string]string{
+		"no json":          "Here are some ideas: research, features",
+		"not an object":    `{"Research": "Competitor analysis"}`,
+		"empty":            `{}`,
+		"empty category":   `{"Research": []}`,
+		"missing title":    `{"Research": [{"reason": "Know the market."}]}`,
+		"untitled subitem": `{"Research": [{"title": "Market", "sub_branches": [{"title": " "}]}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseSuggestions(raw); !errors.Is(err, ErrInvalidSuggestions) {
+				t.Errorf("err = %v, want ErrInvalidSuggestions", err)
+			}
+		})
+	}
+}
+
+func TestBrainstormMessagesIncludeAncestors(t *testing.T) {
+	data := models.BoardData{
+		Nodes: []json.RawMessage{
+			json.RawMessage(`{"id":"root","data":{"label":"Launch plan"}}`),
+			json.RawMessage(`{"id":"mid","data":{"label":"Marketing"}}`),
+			json.RawMessage(`{"id":"leaf","data":{"label":"Social media","content":"Focus on short videos"}}`),
+			json.RawMessage(`{"id":"child","data":{"label":"TikTok"}}`),
+			json.RawMessage(`{"id":"other","data":{"label":"Unrelated"}}`),
+		},
+		Edges: []json.RawMessage{
+			json.RawMessage(`{"id":"e1","source":"root","target":"mid"}`),
+			json.RawMessage(`{"id":"e2","source":"mid","target":"leaf"}`),
+			json.RawMessage(`{"id":"e3","source":"leaf","target":"child"}`),
+		},
+	}
+	graph, err := boardgraph.Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	messages := BrainstormMessages("Q3", graph, graph.Node("leaf"), "More channels")
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	prompt := messages[0].Content
+
+	for _, want := range []string{
+		"- Launch plan\n  - Marketing\n    - Social media (current node)",
+		"Focus on short videos",
+		"- TikTok",
+		"Request: More channels",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt is missing %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "Unrelated") {
+		t.Errorf("prompt includes a node outside the ancestor path:\n%s", prompt)
+	}
+}
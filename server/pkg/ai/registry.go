@@ -0,0 +1,68 @@
+package ai
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Provider names, as used for the keys in a user's AI settings
+const (
+	ProviderOpenAI  = "openai"
+	ProviderClaude  = "claude"
+	ProviderKluster = "klusterai"
+	// ProviderOllama is registered by the server only when a local Ollama
+	// base URL is configured
+	ProviderOllama = "ollama"
+)
+
+// KlusterBaseURL is the API root of kluster.ai
+const KlusterBaseURL = "https://api.kluster.ai/v1"
+
+// HTTPClient is shared by the built-in adapters. Brainstorms can take a
+// while, so the timeout is generous.
+var HTTPClient = &http.Client{Timeout: 2 * time.Minute}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Provider)
+)
+
+func init() {
+	Register(NewOpenAI(OpenAIBaseURL, HTTPClient))
+	Register(NewAnthropic(AnthropicBaseURL, HTTPClient))
+	Register(NewOpenAICompatible(ProviderKluster, KlusterBaseURL, HTTPClient))
+}
+
+// Register makes a provider available to Lookup, replacing any provider with the same name
+func Register(provider Provider) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[provider.Name()] = provider
+}
+
+// Lookup returns the provider registered under name
+func Lookup(name string) (Provider, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	provider, ok := registry[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+// Names returns the names of all registered providers in alphabetical order
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
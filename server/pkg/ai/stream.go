@@ -0,0 +1,123 @@
+package ai
+
+import (
+	"encoding/json"
+	"strings"
+
+	"saas-server/models"
+)
+
+// StreamedConcept is a concept completed while a reply was still streaming
+type StreamedConcept struct {
+	Category string                   `json:"category"`
+	Concept  models.BrainstormConcept `json:"concept"`
+}
+
+// SuggestionStream picks complete concepts out of a brainstorm reply as it
+// streams in, so they can be shown before the model has finished. It scans
+// the category object incrementally: text before the opening brace is
+// skipped, and each object closing directly inside a category's array is
+// decoded as a concept. Concepts that fail validation are left out; the full
+// reply should still be checked with ParseSuggestions once it is complete.
+type SuggestionStream struct {
+	buf      []byte
+	pos      int
+	started  bool
+	done     bool
+	depth    int
+	inString bool
+	escaped  bool
+	// stringStart is the offset of the opening quote of the current string
+	stringStart int
+	// lastKey is the last string completed directly inside the top-level object
+	lastKey  string
+	category string
+	// conceptStart is the offset of the opening brace of the current concept
+	conceptStart int
+}
+
+// Depths within the reply while scanning
+const (
+	depthCategories = 1 // inside the top-level object
+	depthConcepts   = 2 // inside a category's array
+	depthConcept    = 3 // inside a concept object
+)
+
+// Feed adds the next piece of the reply and returns the concepts it completed
+func (s *SuggestionStream) Feed(text string) []StreamedConcept {
+	s.buf = append(s.buf, text...)
+
+	var completed []StreamedConcept
+	for ; s.pos < len(s.buf) && !s.done; s.pos++ {
+		c := s.buf[s.pos]
+
+		if !s.started {
+			if c == '{' {
+				s.started = true
+				s.depth = depthCategories
+			}
+			continue
+		}
+
+		if s.inString {
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case c == '\\':
+				s.escaped = true
+			case c == '"':
+				s.inString = false
+				if s.depth == depthCategories {
+					var key string
+					if err := json.Unmarshal(s.buf[s.stringStart:s.pos+1], &key); err == nil {
+						s.lastKey = key
+					}
+				}
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			s.inString = true
+			s.stringStart = s.pos
+		case '{', '[':
+			s.depth++
+			if s.depth == depthConcepts && c == '[' {
+				s.category = strings.TrimSpace(s.lastKey)
+			}
+			if s.depth == depthConcept && c == '{' {
+				s.conceptStart = s.pos
+			}
+		case '}', ']':
+			if s.depth == depthConcept && c == '}' {
+				if concept, ok := decodeConcept(s.buf[s.conceptStart : s.pos+1]); ok && s.category != "" {
+					completed = append(completed, StreamedConcept{Category: s.category, Concept: concept})
+				}
+			}
+			s.depth--
+			if s.depth == 0 {
+				s.done = true
+			}
+		}
+	}
+
+	return completed
+}
+
+// Text returns everything fed so far
+func (s *SuggestionStream) Text() string {
+	return string(s.buf)
+}
+
+// decodeConcept decodes and cleans a single concept object
+func decodeConcept(raw []byte) (models.BrainstormConcept, bool) {
+	concepts := make([]models.BrainstormConcept, 1)
+	if err := json.Unmarshal(raw, &concepts[0]); err != nil {
+		return models.BrainstormConcept{}, false
+	}
+	if err := cleanConcepts(concepts, 0); err != nil {
+		return models.BrainstormConcept{}, false
+	}
+	return concepts[0], true
+}
@@ -0,0 +1,46 @@
+package ai
+
+import (
+	"testing"
+)
+
+func TestSuggestionStreamEmitsConceptsAsTheyComplete(t *testing.T) {
+	var stream SuggestionStream
+	var got []StreamedConcept
+	for _, c := range brainstormReply {
+		got = append(got, stream.Feed(string(c))...)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d concepts, want 2: %+v", len(got), got)
+	}
+	if got[0].Category != "Research" || got[0].Concept.Title != "Competitor analysis" {
+		t.Errorf("first concept = %+v", got[0])
+	}
+	if len(got[0].Concept.SubBranches) != 1 {
+		t.Errorf("sub_branches = %+v, want them kept on their concept", got[0].Concept.SubBranches)
+	}
+	if got[1].Category != "Features" || got[1].Concept.Title != "Social login" {
+		t.Errorf("second concept = %+v", got[1])
+	}
+
+	if _, err := ParseSuggestions(stream.Text()); err != nil {
+		t.Errorf("ParseSuggestions on the streamed text: %v", err)
+	}
+}
+
+func TestSuggestionStreamHandlesBracesInStrings(t *testing.T) {
+	var stream SuggestionStream
+	got := stream.Feed(`{"Odd {names}": [{"title": "Use \"quotes\" and }", "reason": "[x]"}, {"title": ""}]`)
+	got = append(got, stream.Feed(`, "Next": [{"title": "Later"}]} trailing {"ignored": [{"title": "no"}]}`)...)
+
+	if len(got) != 2 {
+		t.Fatalf("got %d concepts, want 2: %+v", len(got), got)
+	}
+	if got[0].Category != "Odd {names}" || got[0].Concept.Title != `Use "quotes" and }` {
+		t.Errorf("first concept = %+v", got[0])
+	}
+	if got[1].Category != "Next" || got[1].Concept.Title != "Later" {
+		t.Errorf("second concept = %+v", got[1])
+	}
+}
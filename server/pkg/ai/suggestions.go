@@ -0,0 +1,124 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"saas-server/models"
+)
+
+const (
+	// maxConceptTitle is the longest concept title accepted, in characters
+	maxConceptTitle = 200
+	// maxConceptDepth is how deeply sub-branches may nest below a concept
+	maxConceptDepth = 2
+)
+
+// ErrInvalidSuggestions is returned when a model's reply is not valid
+// brainstorm JSON; the wrapping error says what was wrong
+var ErrInvalidSuggestions = errors.New("AI response is not valid brainstorm JSON")
+
+// ParseSuggestions extracts the JSON object from a model's reply and checks
+// that it maps category names to concepts with titles. Text around the
+// object, such as a Markdown code fence, is ignored. Titles and reasons are
+// trimmed.
+func ParseSuggestions(raw string) (models.BrainstormSuggestions, error) {
+	start := strings.Index(raw, "{")
+	end := strings.LastIndex(raw, "}")
+	if start == -1 || end < start {
+		return nil, fmt.Errorf("%w: no JSON object found", ErrInvalidSuggestions)
+	}
+
+	var categories map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &categories); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSuggestions, err)
+	}
+	if len(categories) == 0 {
+		return nil, fmt.Errorf("%w: no categories", ErrInvalidSuggestions)
+	}
+
+	suggestions := make(models.BrainstormSuggestions, len(categories))
+	for name, rawConcepts := range categories {
+		category := strings.TrimSpace(name)
+		if category == "" {
+			return nil, fmt.Errorf("%w: category without a name", ErrInvalidSuggestions)
+		}
+
+		var concepts []models.BrainstormConcept
+		if err := json.Unmarshal(rawConcepts, &concepts); err != nil {
+			return nil, fmt.Errorf("%w: category %q: %v", ErrInvalidSuggestions, category, err)
+		}
+		if err := cleanCategory(category, concepts); err != nil {
+			return nil, err
+		}
+
+		suggestions[category] = append(suggestions[category], concepts...)
+	}
+
+	return suggestions, nil
+}
+
+// CleanSuggestions applies the checks of ParseSuggestions to suggestions
+// that were already decoded, such as those a client sends back to be added
+// to a board. Category names are trimmed in the returned map; titles and
+// reasons are trimmed in place.
+func CleanSuggestions(suggestions models.BrainstormSuggestions) (models.BrainstormSuggestions, error) {
+	if len(suggestions) == 0 {
+		return nil, fmt.Errorf("%w: no categories", ErrInvalidSuggestions)
+	}
+
+	cleaned := make(models.BrainstormSuggestions, len(suggestions))
+	for name, concepts := range suggestions {
+		category := strings.TrimSpace(name)
+		if category == "" {
+			return nil, fmt.Errorf("%w: category without a name", ErrInvalidSuggestions)
+		}
+		if err := cleanCategory(category, concepts); err != nil {
+			return nil, err
+		}
+
+		cleaned[category] = append(cleaned[category], concepts...)
+	}
+
+	return cleaned, nil
+}
+
+// cleanCategory checks that a category has concepts and cleans them
+func cleanCategory(category string, concepts []models.BrainstormConcept) error {
+	if len(concepts) == 0 {
+		return fmt.Errorf("%w: category %q has no concepts", ErrInvalidSuggestions, category)
+	}
+	if err := cleanConcepts(concepts, 0); err != nil {
+		return fmt.Errorf("%w: category %q: %v", ErrInvalidSuggestions, category, err)
+	}
+	return nil
+}
+
+// cleanConcepts trims the text of concepts and their sub-branches and checks
+// that each has a title of reasonable length
+func cleanConcepts(concepts []models.BrainstormConcept, depth int) error {
+	if depth > maxConceptDepth {
+		return fmt.Errorf("sub_branches nested more than %d levels deep", maxConceptDepth)
+	}
+
+	for i := range concepts {
+		concept := &concepts[i]
+		concept.Title = strings.TrimSpace(concept.Title)
+		concept.Reason = strings.TrimSpace(concept.Reason)
+
+		if concept.Title == "" {
+			return fmt.Errorf("concept %d has no title", i)
+		}
+		if utf8.RuneCountInString(concept.Title) > maxConceptTitle {
+			return fmt.Errorf("concept %d title is longer than %d characters", i, maxConceptTitle)
+		}
+		if err := cleanConcepts(concept.SubBranches, depth+1); err != nil {
+			return fmt.Errorf("concept %q: %w", concept.Title, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package ai
+
+import "unicode/utf8"
+
+// charsPerToken is the rough number of characters in a token, used when a
+// provider does not report usage
+const charsPerToken = 4
+
+// EstimateTokens roughly counts the tokens in text
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+// MeteredUsage returns the usage to record for a request. completion is nil
+// when the provider never accepted the request, which uses no tokens.
+// Counts the provider did not report, such as when a stream was cut short
+// or the provider does not report usage at all, are estimated from the
+// prompt and the text received.
+func MeteredUsage(req Request, completion *Completion) Usage {
+	if completion == nil {
+		return Usage{}
+	}
+
+	usage := completion.Usage
+	if usage.PromptTokens == 0 {
+		usage.PromptTokens = EstimateTokens(req.System)
+		for _, message := range req.Messages {
+			usage.PromptTokens += EstimateTokens(message.Content)
+		}
+	}
+	if usage.CompletionTokens == 0 {
+		usage.CompletionTokens = EstimateTokens(completion.Text)
+	}
+	return usage
+}
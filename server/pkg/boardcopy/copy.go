@@ -0,0 +1,127 @@
+// Package boardcopy deep-copies board data with fresh node and edge IDs
+package boardcopy
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"saas-server/models"
+)
+
+// nodeReferenceFields are node fields that hold the ID of another node, used
+// by React Flow for nodes nested inside group nodes
+var nodeReferenceFields = []string{"parentNode", "parentId"}
+
+// Copy returns a copy of data in which every node and edge has a new ID.
+// Edge sources and targets and parent references between nodes are rewritten
+// to the new IDs, and edges whose ends do not exist are dropped. All other
+// fields are copied unchanged.
+func Copy(data models.BoardData) (models.BoardData, error) {
+	return CopyWithIDs(data, func(kind string) string {
+		return fmt.Sprintf("%s-%s", kind, uuid.New().String())
+	})
+}
+
+// CopyWithIDs is Copy with a custom ID generator, called with "node" or "edge"
+func CopyWithIDs(data models.BoardData, newID func(kind string) string) (models.BoardData, error) {
+	ids := make(map[string]string, len(data.Nodes))
+	nodes := make([]map[string]json.RawMessage, 0, len(data.Nodes))
+
+	// First pass: assign every node its new ID
+	for i, raw := range data.Nodes {
+		var node map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &node); err != nil {
+			return data, fmt.Errorf("node %d: %w", i, err)
+		}
+
+		oldID, err := stringField(node, "id")
+		if err != nil {
+			return data, fmt.Errorf("node %d: %w", i, err)
+		}
+
+		id := newID("node")
+		if oldID != "" {
+			ids[oldID] = id
+		}
+		node["id"], _ = json.Marshal(id)
+		nodes = append(nodes, node)
+	}
+
+	// Second pass: now that all IDs are known, rewrite references between nodes
+	result := models.BoardData{
+		Nodes: make([]json.RawMessage, 0, len(nodes)),
+		Edges: make([]json.RawMessage, 0, len(data.Edges)),
+	}
+	for i, node := range nodes {
+		for _, field := range nodeReferenceFields {
+			ref, err := stringField(node, field)
+			if err != nil {
+				return data, fmt.Errorf("node %d: %w", i, err)
+			}
+			if ref == "" {
+				continue
+			}
+			if mapped, ok := ids[ref]; ok {
+				node[field], _ = json.Marshal(mapped)
+			} else {
+				delete(node, field)
+			}
+		}
+
+		raw, err := json.Marshal(node)
+		if err != nil {
+			return data, err
+		}
+		result.Nodes = append(result.Nodes, raw)
+	}
+
+	for i, raw := range data.Edges {
+		var edge map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &edge); err != nil {
+			return data, fmt.Errorf("edge %d: %w", i, err)
+		}
+
+		source, err := stringField(edge, "source")
+		if err != nil {
+			return data, fmt.Errorf("edge %d: %w", i, err)
+		}
+		target, err := stringField(edge, "target")
+		if err != nil {
+			return data, fmt.Errorf("edge %d: %w", i, err)
+		}
+
+		newSource, sourceOK := ids[source]
+		newTarget, targetOK := ids[target]
+		if !sourceOK || !targetOK {
+			continue
+		}
+
+		edge["id"], _ = json.Marshal(newID("edge"))
+		edge["source"], _ = json.Marshal(newSource)
+		edge["target"], _ = json.Marshal(newTarget)
+
+		raw, err := json.Marshal(edge)
+		if err != nil {
+			return data, err
+		}
+		result.Edges = append(result.Edges, raw)
+	}
+
+	return result, nil
+}
+
+// stringField reads an optional string field of a JSON object
+func stringField(object map[string]json.RawMessage, field string) (string, error) {
+	raw, ok := object[field]
+	if !ok || string(raw) == "null" {
+		return "", nil
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("%s must be a string", field)
+	}
+	return value, nil
+}
@@ -0,0 +1,54 @@
+package boardexport
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// dotFormat renders a board as a Graphviz DOT digraph. Node and edge IDs are
+// kept so the output can be matched back to the board.
+type dotFormat struct{}
+
+func (dotFormat) Name() string        { return "dot" }
+func (dotFormat) ContentType() string { return "text/vnd.graphviz; charset=utf-8" }
+func (dotFormat) Extension() string   { return "dot" }
+
+func (dotFormat) Write(w io.Writer, doc *Document) error {
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString("digraph " + dotQuote(doc.Title) + " {\n")
+	bw.WriteString("  graph [label=" + dotQuote(doc.Title) + ", labelloc=t, rankdir=LR];\n")
+	bw.WriteString("  node [shape=box, style=rounded];\n")
+
+	for _, node := range doc.Graph.Nodes {
+		bw.WriteString("  " + dotQuote(node.ID) + " [label=" + dotQuote(node.Title()) + "];\n")
+	}
+
+	for _, edge := range doc.Graph.Edges {
+		bw.WriteString("  " + dotQuote(edge.Source) + " -> " + dotQuote(edge.Target))
+		if edge.Label != "" {
+			bw.WriteString(" [label=" + dotQuote(edge.Label) + "]")
+		}
+		bw.WriteString(";\n")
+	}
+
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// dotEscaper escapes text for a DOT double-quoted string. Backslashes are
+// doubled so user text cannot form escapes such as \l, and line breaks
+// become the \n escape.
+var dotEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// dotQuote returns s as a DOT double-quoted string
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,99 @@
+// Package boardexport renders boards into portable file formats and diagrams. Each format
+// implements Format and registers itself by name, so handlers can look formats
+// up without knowing about them and new ones only need a Register call.
+package boardexport
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"saas-server/pkg/boardgraph"
+)
+
+// ErrUnknownFormat is returned by Lookup for unregistered format names
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Document is a board prepared for export
+type Document struct {
+	Title       string
+	Description string
+	Graph       *boardgraph.Graph
+}
+
+// Format writes a Document in a particular file format
+type Format interface {
+	// Name is the value used to select the format, e.g. "markdown"
+	Name() string
+	// ContentType is the MIME type of the output
+	ContentType() string
+	// Extension is the file extension without a leading dot
+	Extension() string
+	// Write renders doc to w
+	Write(w io.Writer, doc *Document) error
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Format)
+)
+
+// Register makes a format available to Lookup, replacing any format with the same name
+func Register(format Format) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[format.Name()] = format
+}
+
+// Lookup returns the format registered under name
+func Lookup(name string) (Format, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	format, ok := registry[name]
+	if !ok {
+		return nil, ErrUnknownFormat
+	}
+	return format, nil
+}
+
+// Names returns the names of all registered formats in alphabetical order
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// nodeDetails returns the part of a node's content not already shown as its
+// title. A node without a label takes its title from the first content line,
+// which is left out so it is not written twice.
+func nodeDetails(node *boardgraph.Node) string {
+	if node.Label != "" {
+		if node.Content == node.Label {
+			return ""
+		}
+		return node.Content
+	}
+	if parts := strings.SplitN(node.Content, "\n", 2); len(parts) == 2 {
+		return parts[1]
+	}
+	return ""
+}
+
+func init() {
+	Register(markdownFormat{})
+	Register(opmlFormat{})
+	Register(freeMindFormat{})
+	Register(dotFormat{})
+	Register(mermaidFlowchartFormat{})
+	Register(mermaidMindmapFormat{})
+	Register(svgFormat{})
+}
@@ -0,0 +1,73 @@
+package boardexport
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"saas-server/pkg/boardgraph"
+)
+
+// freeMindFormat renders a board as a FreeMind .mm mind map. FreeMind needs a
+// single root, so the board title becomes the root and each tree of the
+// board hangs off it. Node content is attached as a note.
+type freeMindFormat struct{}
+
+func (freeMindFormat) Name() string        { return "mm" }
+func (freeMindFormat) ContentType() string { return "application/x-freemind; charset=utf-8" }
+func (freeMindFormat) Extension() string   { return "mm" }
+
+type freeMindMap struct {
+	XMLName xml.Name     `xml:"map"`
+	Version string       `xml:"version,attr"`
+	Root    freeMindNode `xml:"node"`
+}
+
+type freeMindNode struct {
+	Text     string         `xml:"TEXT,attr"`
+	Position string         `xml:"POSITION,attr,omitempty"`
+	Note     *freeMindNote  `xml:"richcontent,omitempty"`
+	Children []freeMindNode `xml:"node"`
+}
+
+type freeMindNote struct {
+	Type       string   `xml:"TYPE,attr"`
+	Paragraphs []string `xml:"html>body>p"`
+}
+
+func (freeMindFormat) Write(w io.Writer, doc *Document) error {
+	root := freeMindNode{Text: doc.Title}
+	if doc.Description != "" {
+		root.Note = newFreeMindNote(doc.Description)
+	}
+
+	// FreeMind lays first-level nodes out on either side of the root
+	for i, child := range freeMindNodes(doc.Graph.Forest()) {
+		child.Position = "right"
+		if i%2 == 1 {
+			child.Position = "left"
+		}
+		root.Children = append(root.Children, child)
+	}
+
+	return writeXML(w, freeMindMap{Version: "1.0.1", Root: root})
+}
+
+func freeMindNodes(trees []*boardgraph.Tree) []freeMindNode {
+	nodes := make([]freeMindNode, 0, len(trees))
+	for _, tree := range trees {
+		node := freeMindNode{
+			Text:     tree.Node.Title(),
+			Children: freeMindNodes(tree.Children),
+		}
+		if details := nodeDetails(tree.Node); details != "" {
+			node.Note = newFreeMindNote(details)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func newFreeMindNote(text string) *freeMindNote {
+	return &freeMindNote{Type: "NOTE", Paragraphs: strings.Split(text, "\n")}
+}
@@ -0,0 +1,48 @@
+package boardexport
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"saas-server/pkg/boardgraph"
+)
+
+// markdownFormat renders a board as a nested bullet list, one bullet per node.
+// Node content follows its bullet as indented lines.
+type markdownFormat struct{}
+
+func (markdownFormat) Name() string        { return "markdown" }
+func (markdownFormat) ContentType() string { return "text/markdown; charset=utf-8" }
+func (markdownFormat) Extension() string   { return "md" }
+
+func (markdownFormat) Write(w io.Writer, doc *Document) error {
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString("# " + singleLine(doc.Title) + "\n\n")
+	if doc.Description != "" {
+		bw.WriteString(doc.Description + "\n\n")
+	}
+
+	err := boardgraph.Walk(doc.Graph.Forest(), func(tree *boardgraph.Tree, depth int) error {
+		indent := strings.Repeat("  ", depth)
+		bw.WriteString(indent + "- " + singleLine(tree.Node.Title()) + "\n")
+
+		if details := nodeDetails(tree.Node); details != "" {
+			for _, line := range strings.Split(details, "\n") {
+				bw.WriteString(indent + "  " + line + "\n")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// singleLine collapses line breaks so a value fits on one line
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,92 @@
+package boardexport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"saas-server/pkg/boardgraph"
+)
+
+// mermaidFlowchartFormat renders a board as a Mermaid flowchart that keeps
+// every edge, including ones that form cycles
+type mermaidFlowchartFormat struct{}
+
+func (mermaidFlowchartFormat) Name() string        { return "mermaid" }
+func (mermaidFlowchartFormat) ContentType() string { return "text/vnd.mermaid; charset=utf-8" }
+func (mermaidFlowchartFormat) Extension() string   { return "mmd" }
+
+func (mermaidFlowchartFormat) Write(w io.Writer, doc *Document) error {
+	bw := bufio.NewWriter(w)
+
+	// Board IDs may contain characters Mermaid does not accept, so nodes get
+	// short generated IDs instead
+	ids := make(map[string]string, len(doc.Graph.Nodes))
+	for i, node := range doc.Graph.Nodes {
+		ids[node.ID] = fmt.Sprintf("n%d", i+1)
+	}
+
+	bw.WriteString("%% " + singleLine(doc.Title) + "\n")
+	bw.WriteString("flowchart LR\n")
+
+	for _, node := range doc.Graph.Nodes {
+		bw.WriteString("  " + ids[node.ID] + "[" + mermaidQuote(node.Title()) + "]\n")
+	}
+
+	for _, edge := range doc.Graph.Edges {
+		bw.WriteString("  " + ids[edge.Source] + " -->")
+		if edge.Label != "" {
+			bw.WriteString("|" + mermaidQuote(edge.Label) + "|")
+		}
+		bw.WriteString(" " + ids[edge.Target] + "\n")
+	}
+
+	return bw.Flush()
+}
+
+// mermaidMindmapFormat renders a board as a Mermaid mindmap. A mindmap has a
+// single root, so the board title is the root and the board's trees hang off it.
+type mermaidMindmapFormat struct{}
+
+func (mermaidMindmapFormat) Name() string        { return "mermaid-mindmap" }
+func (mermaidMindmapFormat) ContentType() string { return "text/vnd.mermaid; charset=utf-8" }
+func (mermaidMindmapFormat) Extension() string   { return "mmd" }
+
+func (mermaidMindmapFormat) Write(w io.Writer, doc *Document) error {
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString("mindmap\n")
+	bw.WriteString("  root((" + mermaidQuote(doc.Title) + "))\n")
+
+	count := 0
+	err := boardgraph.Walk(doc.Graph.Forest(), func(tree *boardgraph.Tree, depth int) error {
+		count++
+		indent := strings.Repeat("  ", depth+2)
+		bw.WriteString(fmt.Sprintf("%sn%d[%s]\n", indent, count, mermaidQuote(tree.Node.Title())))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// mermaidEscaper replaces characters that end or break a quoted Mermaid label
+// with Mermaid entity codes. "#" is escaped first so user text cannot form
+// entities of its own.
+var mermaidEscaper = strings.NewReplacer(
+	"#", "#35;",
+	`"`, "#quot;",
+	"<", "#lt;",
+	">", "#gt;",
+	"\r\n", "<br/>",
+	"\n", "<br/>",
+	"\r", "<br/>",
+)
+
+// mermaidQuote returns s as a double-quoted Mermaid label
+func mermaidQuote(s string) string {
+	return `"` + mermaidEscaper.Replace(s) + `"`
+}
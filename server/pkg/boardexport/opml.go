@@ -0,0 +1,67 @@
+package boardexport
+
+import (
+	"encoding/xml"
+	"io"
+
+	"saas-server/pkg/boardgraph"
+)
+
+// opmlFormat renders a board as an OPML 2.0 outline. Node content is kept in
+// the _note attribute used by most outliners.
+type opmlFormat struct{}
+
+func (opmlFormat) Name() string        { return "opml" }
+func (opmlFormat) ContentType() string { return "text/x-opml; charset=utf-8" }
+func (opmlFormat) Extension() string   { return "opml" }
+
+type opmlDocument struct {
+	XMLName xml.Name      `xml:"opml"`
+	Version string        `xml:"version,attr"`
+	Title   string        `xml:"head>title"`
+	Body    []opmlOutline `xml:"body>outline"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Note     string        `xml:"_note,attr,omitempty"`
+	Children []opmlOutline `xml:"outline"`
+}
+
+func (opmlFormat) Write(w io.Writer, doc *Document) error {
+	out := opmlDocument{
+		Version: "2.0",
+		Title:   doc.Title,
+		Body:    opmlOutlines(doc.Graph.Forest()),
+	}
+	return writeXML(w, out)
+}
+
+func opmlOutlines(trees []*boardgraph.Tree) []opmlOutline {
+	outlines := make([]opmlOutline, 0, len(trees))
+	for _, tree := range trees {
+		outline := opmlOutline{
+			Text:     tree.Node.Title(),
+			Children: opmlOutlines(tree.Children),
+		}
+		outline.Note = nodeDetails(tree.Node)
+		outlines = append(outlines, outline)
+	}
+	return outlines
+}
+
+// writeXML writes v as an indented XML document with a declaration
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,216 @@
+package boardexport
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"saas-server/pkg/boardgraph"
+)
+
+// SVG drawing constants, in canvas pixels
+const (
+	svgMargin         = 40
+	svgDefaultWidth   = 180
+	svgMaxWidth       = 280
+	svgMinHeight      = 44
+	svgLineHeight     = 18
+	svgPadding        = 12
+	svgCharWidth      = 7.5
+	svgMaxLabelLines  = 4
+	svgMaxDetailLines = 3
+)
+
+// svgFormat draws a board as a standalone SVG image using the node positions
+// stored by the editor, so the picture matches what users see on the canvas
+type svgFormat struct{}
+
+func (svgFormat) Name() string        { return "svg" }
+func (svgFormat) ContentType() string { return "image/svg+xml; charset=utf-8" }
+func (svgFormat) Extension() string   { return "svg" }
+
+// svgBox is a node with its computed size and text lines
+type svgBox struct {
+	x, y          float64
+	width, height float64
+	label         []string
+	details       []string
+}
+
+func (svgFormat) Write(w io.Writer, doc *Document) error {
+	boxes := make(map[string]*svgBox, len(doc.Graph.Nodes))
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	for _, node := range doc.Graph.Nodes {
+		box := newSVGBox(node)
+		boxes[node.ID] = box
+		minX = math.Min(minX, box.x)
+		minY = math.Min(minY, box.y)
+		maxX = math.Max(maxX, box.x+box.width)
+		maxY = math.Max(maxY, box.y+box.height)
+	}
+	if len(boxes) == 0 {
+		minX, minY, maxX, maxY = 0, 0, svgDefaultWidth, svgMinHeight
+	}
+
+	// Shift the drawing so the top-left node sits at the margin
+	offsetX := svgMargin - minX
+	offsetY := svgMargin - minY
+	width := maxX - minX + 2*svgMargin
+	height := maxY - minY + 2*svgMargin
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s" font-family="Helvetica, Arial, sans-serif" font-size="14">`+"\n",
+		svgNum(width), svgNum(height), svgNum(width), svgNum(height))
+	bw.WriteString("  <title>" + svgEscape(doc.Title) + "</title>\n")
+	bw.WriteString(`  <defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M 0 0 L 10 5 L 0 10 z" fill="#94a3b8"/></marker></defs>` + "\n")
+	fmt.Fprintf(bw, `  <rect width="%s" height="%s" fill="#ffffff"/>`+"\n", svgNum(width), svgNum(height))
+
+	// Edges go first so nodes are drawn on top of them
+	bw.WriteString(`  <g fill="none" stroke="#94a3b8" stroke-width="1.5">` + "\n")
+	for _, edge := range doc.Graph.Edges {
+		source, target := boxes[edge.Source], boxes[edge.Target]
+		if source == nil || target == nil || source == target {
+			continue
+		}
+		x1, y1, x2, y2, c1, c2 := svgEdgePoints(source, target)
+		fmt.Fprintf(bw, `    <path d="M %s %s C %s %s, %s %s, %s %s" marker-end="url(#arrow)"/>`+"\n",
+			svgNum(x1+offsetX), svgNum(y1+offsetY),
+			svgNum(c1+offsetX), svgNum(y1+offsetY),
+			svgNum(c2+offsetX), svgNum(y2+offsetY),
+			svgNum(x2+offsetX), svgNum(y2+offsetY))
+	}
+	bw.WriteString("  </g>\n")
+
+	for _, edge := range doc.Graph.Edges {
+		source, target := boxes[edge.Source], boxes[edge.Target]
+		if edge.Label == "" || source == nil || target == nil || source == target {
+			continue
+		}
+		x1, y1, x2, y2, _, _ := svgEdgePoints(source, target)
+		fmt.Fprintf(bw, `  <text x="%s" y="%s" text-anchor="middle" font-size="12" fill="#64748b">%s</text>`+"\n",
+			svgNum((x1+x2)/2+offsetX), svgNum((y1+y2)/2+offsetY-4), svgEscape(singleLine(edge.Label)))
+	}
+
+	for _, node := range doc.Graph.Nodes {
+		box := boxes[node.ID]
+		x, y := box.x+offsetX, box.y+offsetY
+
+		bw.WriteString("  <g>\n")
+		fmt.Fprintf(bw, `    <rect x="%s" y="%s" width="%s" height="%s" rx="8" fill="#f8fafc" stroke="#cbd5e1"/>`+"\n",
+			svgNum(x), svgNum(y), svgNum(box.width), svgNum(box.height))
+
+		lineY := y + svgPadding + svgLineHeight - 4
+		for _, line := range box.label {
+			fmt.Fprintf(bw, `    <text x="%s" y="%s" font-weight="bold" fill="#0f172a">%s</text>`+"\n",
+				svgNum(x+svgPadding), svgNum(lineY), svgEscape(line))
+			lineY += svgLineHeight
+		}
+		for _, line := range box.details {
+			fmt.Fprintf(bw, `    <text x="%s" y="%s" font-size="12" fill="#475569">%s</text>`+"\n",
+				svgNum(x+svgPadding), svgNum(lineY), svgEscape(line))
+			lineY += svgLineHeight
+		}
+		bw.WriteString("  </g>\n")
+	}
+
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+// newSVGBox sizes a node. The size saved by the editor is used when present;
+// otherwise it is estimated from the wrapped text.
+func newSVGBox(node *boardgraph.Node) *svgBox {
+	box := &svgBox{x: node.X, y: node.Y, width: node.Width, height: node.Height}
+	if box.width <= 0 {
+		box.width = svgDefaultWidth
+		if estimated := float64(utf8.RuneCountInString(node.Title()))*svgCharWidth + 2*svgPadding; estimated > box.width {
+			box.width = math.Min(estimated, svgMaxWidth)
+		}
+	}
+
+	charsPerLine := int((box.width - 2*svgPadding) / svgCharWidth)
+	box.label = wrapText(node.Title(), charsPerLine, svgMaxLabelLines)
+	if details := nodeDetails(node); details != "" {
+		box.details = wrapText(details, charsPerLine, svgMaxDetailLines)
+	}
+
+	textHeight := float64(len(box.label)+len(box.details))*svgLineHeight + 2*svgPadding
+	if box.height <= 0 {
+		box.height = math.Max(svgMinHeight, textHeight)
+	}
+
+	return box
+}
+
+// svgEdgePoints returns where an edge leaves its source and enters its
+// target, plus the x coordinates of the curve's two control points. Edges
+// run from the right side of the source to the left side of the target,
+// like the editor's default handles.
+func svgEdgePoints(source, target *svgBox) (x1, y1, x2, y2, c1, c2 float64) {
+	x1 = source.x + source.width
+	y1 = source.y + source.height/2
+	x2 = target.x
+	y2 = target.y + target.height/2
+
+	bend := math.Max(math.Abs(x2-x1)/2, 40)
+	return x1, y1, x2, y2, x1 + bend, x2 - bend
+}
+
+// wrapText breaks text into at most maxLines lines of about width characters,
+// ending with an ellipsis if it had to be cut
+func wrapText(text string, width, maxLines int) []string {
+	if width < 1 {
+		width = 1
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			for utf8.RuneCountInString(word) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				runes := []rune(word)
+				lines = append(lines, string(runes[:width]))
+				word = string(runes[width:])
+			}
+			switch {
+			case line == "":
+				line = word
+			case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		lines[maxLines-1] += "…"
+	}
+	return lines
+}
+
+// svgEscape escapes text for use in SVG content and attributes
+func svgEscape(s string) string {
+	return html.EscapeString(s)
+}
+
+// svgNum formats a coordinate without needless decimals
+func svgNum(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
@@ -0,0 +1,201 @@
+// Package boardgraph reads the React Flow nodes and edges stored in a board's
+// data into a typed graph and turns it into a forest of trees for formats
+// that need a hierarchy, such as outlines and mind maps
+package boardgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"saas-server/models"
+)
+
+// Node is a board node with the fields exporters care about
+type Node struct {
+	ID      string
+	Type    string
+	Label   string
+	Content string
+	X       float64
+	Y       float64
+	// Width and Height are the rendered size saved by the client, or zero if unknown
+	Width  float64
+	Height float64
+}
+
+// Title returns the node's label, falling back to the first line of its
+// content and then to its ID so every node has something to display
+func (n *Node) Title() string {
+	if n.Label != "" {
+		return n.Label
+	}
+	if n.Content != "" {
+		return strings.SplitN(n.Content, "\n", 2)[0]
+	}
+	return n.ID
+}
+
+// Edge is a directed connection between two nodes
+type Edge struct {
+	ID     string
+	Source string
+	Target string
+	Label  string
+}
+
+// Graph holds the nodes and edges of a board in their stored order. Edges
+// that point at missing nodes are dropped while parsing.
+type Graph struct {
+	Nodes []*Node
+	Edges []Edge
+	byID  map[string]*Node
+}
+
+// rawNode mirrors the subset of a React Flow node that is read
+type rawNode struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Position struct {
+		X float64 `json:"x"`
+		Y float64 `json:"y"`
+	} `json:"position"`
+	Width    float64 `json:"width"`
+	Height   float64 `json:"height"`
+	Measured struct {
+		Width  float64 `json:"width"`
+		Height float64 `json:"height"`
+	} `json:"measured"`
+	Data struct {
+		Label   interface{}     `json:"label"`
+		Content json.RawMessage `json:"content"`
+	} `json:"data"`
+}
+
+// rawEdge mirrors the subset of a React Flow edge that is read
+type rawEdge struct {
+	ID     string      `json:"id"`
+	Source string      `json:"source"`
+	Target string      `json:"target"`
+	Label  interface{} `json:"label"`
+}
+
+// Parse builds a Graph from board data. Nodes without an ID and edges whose
+// source or target is unknown are skipped rather than treated as errors.
+func Parse(data models.BoardData) (*Graph, error) {
+	g := &Graph{byID: make(map[string]*Node, len(data.Nodes))}
+
+	for i, raw := range data.Nodes {
+		var rn rawNode
+		if err := json.Unmarshal(raw, &rn); err != nil {
+			return nil, fmt.Errorf("node %d: %w", i, err)
+		}
+		if rn.ID == "" {
+			continue
+		}
+		if _, exists := g.byID[rn.ID]; exists {
+			continue
+		}
+
+		node := &Node{
+			ID:      rn.ID,
+			Type:    rn.Type,
+			Label:   strings.TrimSpace(stringValue(rn.Data.Label)),
+			Content: PlainText(contentText(rn.Data.Content)),
+			X:       rn.Position.X,
+			Y:       rn.Position.Y,
+			Width:   rn.Width,
+			Height:  rn.Height,
+		}
+		if node.Width == 0 || node.Height == 0 {
+			node.Width, node.Height = rn.Measured.Width, rn.Measured.Height
+		}
+		g.Nodes = append(g.Nodes, node)
+		g.byID[node.ID] = node
+	}
+
+	for i, raw := range data.Edges {
+		var re rawEdge
+		if err := json.Unmarshal(raw, &re); err != nil {
+			return nil, fmt.Errorf("edge %d: %w", i, err)
+		}
+		if g.byID[re.Source] == nil || g.byID[re.Target] == nil {
+			continue
+		}
+		g.Edges = append(g.Edges, Edge{
+			ID:     re.ID,
+			Source: re.Source,
+			Target: re.Target,
+			Label:  strings.TrimSpace(stringValue(re.Label)),
+		})
+	}
+
+	return g, nil
+}
+
+// Node returns the node with the given ID, or nil
+func (g *Graph) Node(id string) *Node {
+	return g.byID[id]
+}
+
+// contentText extracts the text of a node's content, which is either a
+// string or an object of the form {"text": "...", "images": [...]}
+func contentText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+
+	var content struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &content); err == nil {
+		return content.Text
+	}
+
+	return ""
+}
+
+// stringValue converts a JSON scalar to a string; other values become empty
+func stringValue(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case float64, bool:
+		return fmt.Sprint(value)
+	default:
+		return ""
+	}
+}
+
+var (
+	blockTagPattern = regexp.MustCompile(`(?i)<\s*(br|/p|/div|/li|/h[1-6])\s*/?>`)
+	tagPattern      = regexp.MustCompile(`<[^>]*>`)
+	blankLines      = regexp.MustCompile(`\n{2,}`)
+)
+
+// PlainText converts the HTML produced by the node editor to plain text,
+// keeping line breaks between blocks
+func PlainText(s string) string {
+	if !strings.Contains(s, "<") && !strings.Contains(s, "&") {
+		return strings.TrimSpace(s)
+	}
+
+	s = blockTagPattern.ReplaceAllString(s, "\n")
+	s = tagPattern.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	s = strings.Join(lines, "\n")
+
+	return strings.TrimSpace(blankLines.ReplaceAllString(s, "\n"))
+}
@@ -0,0 +1,111 @@
+package boardgraph
+
+// Tree is a node placed in a hierarchy together with its children
+type Tree struct {
+	Node     *Node
+	Children []*Tree
+}
+
+// Forest arranges the graph into trees by following edges from source to
+// target. Nodes with no incoming edge are roots, in board order. Every node
+// appears exactly once: a node with several parents is placed under the first
+// one reached, and edges that would revisit a node are ignored so cycles
+// cannot loop. Nodes that are only reachable through a cycle start a new tree.
+func (g *Graph) Forest() []*Tree {
+	children := make(map[string][]string, len(g.Nodes))
+	incoming := make(map[string]int, len(g.Nodes))
+	for _, edge := range g.Edges {
+		if edge.Source == edge.Target {
+			continue
+		}
+		children[edge.Source] = append(children[edge.Source], edge.Target)
+		incoming[edge.Target]++
+	}
+
+	visited := make(map[string]bool, len(g.Nodes))
+	var build func(node *Node) *Tree
+	build = func(node *Node) *Tree {
+		visited[node.ID] = true
+		tree := &Tree{Node: node}
+		for _, childID := range children[node.ID] {
+			if visited[childID] {
+				continue
+			}
+			tree.Children = append(tree.Children, build(g.byID[childID]))
+		}
+		return tree
+	}
+
+	var forest []*Tree
+	for _, node := range g.Nodes {
+		if incoming[node.ID] == 0 && !visited[node.ID] {
+			forest = append(forest, build(node))
+		}
+	}
+
+	// Whatever is left sits on a cycle with no way in from a root
+	for _, node := range g.Nodes {
+		if !visited[node.ID] {
+			forest = append(forest, build(node))
+		}
+	}
+
+	return forest
+}
+
+// Walk visits every tree in the forest depth-first, parents before children.
+// Depth is zero for roots. Walking stops at the first error returned by fn.
+func Walk(forest []*Tree, fn func(tree *Tree, depth int) error) error {
+	var walk func(trees []*Tree, depth int) error
+	walk = func(trees []*Tree, depth int) error {
+		for _, tree := range trees {
+			if err := fn(tree, depth); err != nil {
+				return err
+			}
+			if err := walk(tree.Children, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(forest, 0)
+}
+
+// Ancestors returns the chain of parents above the node with the given ID,
+// starting at its root. A node with several parents follows the first edge
+// pointing at it, and the chain stops before it would revisit a node.
+func (g *Graph) Ancestors(id string) []*Node {
+	parent := make(map[string]string, len(g.Nodes))
+	for _, edge := range g.Edges {
+		if edge.Source == edge.Target {
+			continue
+		}
+		if _, ok := parent[edge.Target]; !ok {
+			parent[edge.Target] = edge.Source
+		}
+	}
+
+	visited := map[string]bool{id: true}
+	var chain []*Node
+	for current, ok := parent[id]; ok && !visited[current]; current, ok = parent[current] {
+		visited[current] = true
+		chain = append(chain, g.byID[current])
+	}
+
+	// The chain was collected from the node upwards
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// Children returns the nodes the node with the given ID has edges to, in edge order
+func (g *Graph) Children(id string) []*Node {
+	var children []*Node
+	for _, edge := range g.Edges {
+		if edge.Source == id && edge.Target != id {
+			children = append(children, g.byID[edge.Target])
+		}
+	}
+	return children
+}
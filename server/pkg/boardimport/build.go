@@ -0,0 +1,155 @@
+package boardimport
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+
+	"saas-server/models"
+)
+
+// Layout spacing in canvas pixels. Trees grow left to right: each level is
+// a column and each leaf gets its own row.
+const (
+	ColumnWidth = 320
+	RowHeight   = 110
+)
+
+type boardNode struct {
+	ID       string        `json:"id"`
+	Type     string        `json:"type"`
+	Position boardPosition `json:"position"`
+	Data     boardNodeData `json:"data"`
+}
+
+type boardPosition struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type boardNodeData struct {
+	Label   string           `json:"label"`
+	Content boardNodeContent `json:"content"`
+}
+
+type boardNodeContent struct {
+	Text   string   `json:"text"`
+	Images []string `json:"images"`
+	IsHTML bool     `json:"isHtml"`
+}
+
+type boardEdge struct {
+	ID           string `json:"id"`
+	Source       string `json:"source"`
+	Target       string `json:"target"`
+	Type         string `json:"type"`
+	SourceHandle string `json:"sourceHandle"`
+	TargetHandle string `json:"targetHandle"`
+}
+
+// builder accumulates nodes and edges while walking an outline
+type builder struct {
+	nodes   []json.RawMessage
+	edges   []json.RawMessage
+	nextRow int
+}
+
+// Build converts an outline into board data. Every item becomes a textNode
+// connected to its parent by an edge. Positions come from a simple tidy tree
+// layout: leaves are stacked in rows, parents are centred on their children
+// and separate trees are divided by an empty row. The output only depends on
+// the outline, so importing the same file twice gives the same board.
+func Build(outline *Outline) (models.BoardData, error) {
+	b := &builder{
+		nodes: []json.RawMessage{},
+		edges: []json.RawMessage{},
+	}
+
+	for i, item := range outline.Items {
+		if i > 0 {
+			b.nextRow++
+		}
+		if _, _, err := b.place(item, "", 0); err != nil {
+			return models.BoardData{}, err
+		}
+	}
+
+	return models.BoardData{Nodes: b.nodes, Edges: b.edges}, nil
+}
+
+// place lays out an item and its descendants and returns the item's node ID and y position
+func (b *builder) place(item *Item, parentID string, depth int) (string, float64, error) {
+	id := fmt.Sprintf("node-%d", len(b.nodes)+1)
+
+	// Reserve the node's slot so IDs follow document order
+	b.nodes = append(b.nodes, nil)
+	index := len(b.nodes) - 1
+
+	if parentID != "" {
+		edge, err := json.Marshal(boardEdge{
+			ID:           fmt.Sprintf("edge-%d", len(b.edges)+1),
+			Source:       parentID,
+			Target:       id,
+			Type:         "default",
+			SourceHandle: "right",
+			TargetHandle: "left",
+		})
+		if err != nil {
+			return "", 0, err
+		}
+		b.edges = append(b.edges, edge)
+	}
+
+	var y float64
+	if len(item.Children) == 0 {
+		y = float64(b.nextRow * RowHeight)
+		b.nextRow++
+	} else {
+		var first, last float64
+		for i, child := range item.Children {
+			_, childY, err := b.place(child, id, depth+1)
+			if err != nil {
+				return "", 0, err
+			}
+			if i == 0 {
+				first = childY
+			}
+			last = childY
+		}
+		y = (first + last) / 2
+	}
+
+	node, err := json.Marshal(boardNode{
+		ID:       id,
+		Type:     "textNode",
+		Position: boardPosition{X: float64(depth * ColumnWidth), Y: y},
+		Data: boardNodeData{
+			Label: item.Label,
+			Content: boardNodeContent{
+				Text:   contentHTML(item.Content),
+				Images: []string{},
+				IsHTML: true,
+			},
+		},
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	b.nodes[index] = node
+
+	return id, y, nil
+}
+
+// contentHTML converts plain text content to the paragraph HTML used by the node editor
+func contentHTML(text string) string {
+	if text == "" {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		sb.WriteString("<p>" + html.EscapeString(line) + "</p>")
+	}
+	return sb.String()
+}
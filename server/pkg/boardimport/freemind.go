@@ -0,0 +1,73 @@
+package boardimport
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"saas-server/pkg/boardgraph"
+)
+
+type freeMindMap struct {
+	XMLName xml.Name     `xml:"map"`
+	Root    freeMindNode `xml:"node"`
+}
+
+type freeMindNode struct {
+	Text        string                `xml:"TEXT,attr"`
+	RichContent []freeMindRichContent `xml:"richcontent"`
+	Children    []freeMindNode        `xml:"node"`
+}
+
+type freeMindRichContent struct {
+	Type string `xml:"TYPE,attr"`
+	HTML string `xml:",innerxml"`
+}
+
+// parseFreeMind reads a FreeMind or Freeplane .mm map. The central node names
+// the board and its children become the top-level items.
+func parseFreeMind(r io.Reader) (*Outline, error) {
+	var doc freeMindMap
+	if err := newXMLDecoder(r).Decode(&doc); err != nil {
+		return nil, invalidFile(FormatFreeMind, err)
+	}
+
+	root := freeMindItem(doc.Root)
+	outline := &Outline{
+		Title:       root.Label,
+		Description: root.Content,
+		Items:       root.Children,
+	}
+
+	// A map with nothing but a central topic still imports as one node
+	if len(outline.Items) == 0 && root.Label != "" {
+		outline.Items = []*Item{root}
+	}
+
+	return outline, nil
+}
+
+func freeMindItem(node freeMindNode) *Item {
+	item := &Item{Label: boardgraph.PlainText(node.Text)}
+
+	for _, rich := range node.RichContent {
+		text := boardgraph.PlainText(rich.HTML)
+		switch strings.ToUpper(rich.Type) {
+		case "NODE":
+			if item.Label == "" {
+				item.Label = text
+			}
+		case "NOTE", "DETAILS":
+			if item.Content != "" && text != "" {
+				item.Content += "\n"
+			}
+			item.Content += text
+		}
+	}
+
+	for _, child := range node.Children {
+		item.Children = append(item.Children, freeMindItem(child))
+	}
+
+	return item
+}
@@ -0,0 +1,106 @@
+// Package boardimport reads outlines and mind maps made in other tools and
+// turns them into board data with laid out nodes and parent to child edges
+package boardimport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Supported import formats
+const (
+	FormatOPML     = "opml"
+	FormatMarkdown = "markdown"
+	FormatFreeMind = "mm"
+)
+
+// MaxItems caps the number of nodes an imported file may produce
+const MaxItems = 2000
+
+// ErrUnsupportedFormat is returned for formats that cannot be imported
+var ErrUnsupportedFormat = errors.New("unsupported import format")
+
+// ErrInvalidFile is wrapped by every error caused by the content of a file
+var ErrInvalidFile = errors.New("invalid import file")
+
+// ErrEmptyOutline is returned when a file parses but contains no items
+var ErrEmptyOutline = errors.New("file contains no items to import")
+
+// ErrTooManyItems is returned when a file has more than MaxItems items
+var ErrTooManyItems = fmt.Errorf("file contains more than %d items", MaxItems)
+
+// Item is a single entry of an outline
+type Item struct {
+	Label    string
+	Content  string
+	Children []*Item
+}
+
+// Outline is the format-independent result of parsing a file
+type Outline struct {
+	Title       string
+	Description string
+	Items       []*Item
+}
+
+// DetectFormat guesses the format of a file from its name. It returns an
+// empty string if the extension is not recognised.
+func DetectFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".opml", ".xml":
+		return FormatOPML
+	case ".md", ".markdown", ".txt":
+		return FormatMarkdown
+	case ".mm":
+		return FormatFreeMind
+	default:
+		return ""
+	}
+}
+
+// Parse reads a file in the given format into an Outline
+func Parse(format string, r io.Reader) (*Outline, error) {
+	var outline *Outline
+	var err error
+
+	switch format {
+	case FormatOPML:
+		outline, err = parseOPML(r)
+	case FormatMarkdown:
+		outline, err = parseMarkdown(r)
+	case FormatFreeMind:
+		outline, err = parseFreeMind(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	count := countItems(outline.Items)
+	if count == 0 {
+		return nil, ErrEmptyOutline
+	}
+	if count > MaxItems {
+		return nil, ErrTooManyItems
+	}
+
+	return outline, nil
+}
+
+// countItems counts the items of a list and all their descendants
+func countItems(items []*Item) int {
+	count := len(items)
+	for _, item := range items {
+		count += countItems(item.Children)
+	}
+	return count
+}
+
+// invalidFile wraps a parse error with ErrInvalidFile
+func invalidFile(format string, err error) error {
+	return fmt.Errorf("%w: %s: %v", ErrInvalidFile, format, err)
+}
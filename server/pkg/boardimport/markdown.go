@@ -0,0 +1,133 @@
+package boardimport
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	headingPattern  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletPattern   = regexp.MustCompile(`^([ \t]*)(?:[-*+]|\d+[.)])\s+(.*)$`)
+	checkboxPattern = regexp.MustCompile(`^\[[ xX]\]\s+`)
+)
+
+// markdownFrame is an open list item or heading while parsing
+type markdownFrame struct {
+	indent int
+	item   *Item
+}
+
+// parseMarkdown reads a Markdown outline. A leading level-one heading names
+// the board and text before the first item describes it. Other headings and
+// bullet or numbered list items become items nested by heading level and
+// indentation, and other lines are added to the content of the item above.
+func parseMarkdown(r io.Reader) (*Outline, error) {
+	outline := &Outline{}
+	root := &Item{}
+
+	// headings[level] is the most recent heading of that level
+	var headings [7]*Item
+	var bullets []markdownFrame
+	var last *Item
+	var description []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if match := headingPattern.FindStringSubmatch(line); match != nil {
+			level := len(match[1])
+			text := match[2]
+
+			if level == 1 && outline.Title == "" && last == nil {
+				outline.Title = text
+				continue
+			}
+
+			parent := root
+			for l := level - 1; l >= 1; l-- {
+				if headings[l] != nil {
+					parent = headings[l]
+					break
+				}
+			}
+
+			item := &Item{Label: text}
+			parent.Children = append(parent.Children, item)
+			headings[level] = item
+			for l := level + 1; l < len(headings); l++ {
+				headings[l] = nil
+			}
+			bullets = bullets[:0]
+			last = item
+			continue
+		}
+
+		if match := bulletPattern.FindStringSubmatch(line); match != nil {
+			indent := indentWidth(match[1])
+			text := checkboxPattern.ReplaceAllString(match[2], "")
+
+			for len(bullets) > 0 && bullets[len(bullets)-1].indent >= indent {
+				bullets = bullets[:len(bullets)-1]
+			}
+
+			parent := currentHeading(root, headings[:])
+			if len(bullets) > 0 {
+				parent = bullets[len(bullets)-1].item
+			}
+
+			item := &Item{Label: text}
+			parent.Children = append(parent.Children, item)
+			bullets = append(bullets, markdownFrame{indent: indent, item: item})
+			last = item
+			continue
+		}
+
+		text := strings.TrimSpace(line)
+		if last == nil {
+			description = append(description, text)
+			continue
+		}
+		if last.Content != "" {
+			last.Content += "\n"
+		}
+		last.Content += text
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, invalidFile(FormatMarkdown, err)
+	}
+
+	outline.Description = strings.Join(description, "\n")
+	outline.Items = root.Children
+	return outline, nil
+}
+
+// currentHeading returns the deepest open heading, or root if there is none
+func currentHeading(root *Item, headings []*Item) *Item {
+	for l := len(headings) - 1; l >= 1; l-- {
+		if headings[l] != nil {
+			return headings[l]
+		}
+	}
+	return root
+}
+
+// indentWidth measures leading whitespace, counting a tab as four spaces
+func indentWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if r == '\t' {
+			width += 4
+		} else {
+			width++
+		}
+	}
+	return width
+}
@@ -0,0 +1,61 @@
+package boardimport
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"saas-server/pkg/boardgraph"
+)
+
+type opmlDocument struct {
+	Title    string        `xml:"head>title"`
+	Outlines []opmlOutline `xml:"body>outline"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr"`
+	Note     string        `xml:"_note,attr"`
+	Children []opmlOutline `xml:"outline"`
+}
+
+// parseOPML reads an OPML 1.0 or 2.0 outline. The text attribute is the label
+// (falling back to title) and the _note attribute the content.
+func parseOPML(r io.Reader) (*Outline, error) {
+	var doc opmlDocument
+	if err := newXMLDecoder(r).Decode(&doc); err != nil {
+		return nil, invalidFile(FormatOPML, err)
+	}
+
+	return &Outline{
+		Title: strings.TrimSpace(doc.Title),
+		Items: opmlItems(doc.Outlines),
+	}, nil
+}
+
+func opmlItems(outlines []opmlOutline) []*Item {
+	items := make([]*Item, 0, len(outlines))
+	for _, outline := range outlines {
+		label := outline.Text
+		if label == "" {
+			label = outline.Title
+		}
+		items = append(items, &Item{
+			Label:    boardgraph.PlainText(label),
+			Content:  strings.TrimSpace(outline.Note),
+			Children: opmlItems(outline.Children),
+		})
+	}
+	return items
+}
+
+// newXMLDecoder returns a lenient decoder that understands HTML entities,
+// which outliners and FreeMind commonly write
+func newXMLDecoder(r io.Reader) *xml.Decoder {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	decoder.AutoClose = xml.HTMLAutoClose
+	return decoder
+}
@@ -0,0 +1,307 @@
+package boardlayout
+
+import (
+	"math"
+	"sort"
+
+	"saas-server/pkg/boardgraph"
+)
+
+const (
+	// orderingSweeps is the number of barycenter passes used to reduce crossings
+	orderingSweeps = 24
+	// placementSweeps is the number of passes used to straighten edges
+	placementSweeps = 8
+	// dummyHeight is the room reserved for an edge passing through a level
+	dummyHeight = 10
+)
+
+// layeredLayout draws the board as a layered graph in the style of Sugiyama
+// et al., which suits boards that are not trees because nodes have several
+// parents. Cycles are broken by reversing back edges, nodes are assigned to
+// levels by longest path, edges spanning several levels are routed through
+// placeholder vertices, crossings are reduced with the barycenter heuristic
+// and nodes are finally pulled towards their neighbours.
+type layeredLayout struct{}
+
+func (layeredLayout) Name() string { return "layered" }
+
+// layeredVertex is a node, or a placeholder on an edge spanning several levels
+type layeredVertex struct {
+	node   *boardgraph.Node
+	level  int
+	height float64
+	preds  []int
+	succs  []int
+	center float64
+}
+
+func (layeredLayout) Layout(g *boardgraph.Graph) map[string]Position {
+	positions := make(map[string]Position, len(g.Nodes))
+	if len(g.Nodes) == 0 {
+		return positions
+	}
+
+	index := make(map[string]int, len(g.Nodes))
+	for i, node := range g.Nodes {
+		index[node.ID] = i
+	}
+
+	edges := acyclicEdges(g, index)
+	levels := longestPathLevels(len(g.Nodes), edges)
+
+	// Build the vertices, adding placeholders so every edge joins adjacent levels
+	vertices := make([]*layeredVertex, len(g.Nodes))
+	for i, node := range g.Nodes {
+		_, height := NodeSize(node)
+		vertices[i] = &layeredVertex{node: node, level: levels[i], height: height}
+	}
+	for _, edge := range edges {
+		from := edge[0]
+		for level := levels[edge[0]] + 1; level < levels[edge[1]]; level++ {
+			vertices = append(vertices, &layeredVertex{level: level, height: dummyHeight})
+			dummy := len(vertices) - 1
+			vertices[from].succs = append(vertices[from].succs, dummy)
+			vertices[dummy].preds = append(vertices[dummy].preds, from)
+			from = dummy
+		}
+		vertices[from].succs = append(vertices[from].succs, edge[1])
+		vertices[edge[1]].preds = append(vertices[edge[1]].preds, from)
+	}
+
+	// Group vertices by level, initially in board order
+	var layers [][]int
+	var widths []float64
+	for i, vertex := range vertices {
+		for len(layers) <= vertex.level {
+			layers = append(layers, nil)
+		}
+		layers[vertex.level] = append(layers[vertex.level], i)
+		if vertex.node != nil {
+			width, _ := NodeSize(vertex.node)
+			widths = growWidths(widths, vertex.level, width)
+		}
+	}
+	widths = growWidths(widths, len(layers)-1, 0)
+
+	orderLayers(vertices, layers)
+	placeLayers(vertices, layers)
+
+	columns := columnOffsets(widths)
+	minY := math.Inf(1)
+	for _, vertex := range vertices {
+		minY = math.Min(minY, vertex.center-vertex.height/2)
+	}
+	for _, vertex := range vertices {
+		if vertex.node != nil {
+			positions[vertex.node.ID] = Position{
+				X: columns[vertex.level],
+				Y: vertex.center - vertex.height/2 - minY,
+			}
+		}
+	}
+
+	return positions
+}
+
+// acyclicEdges returns the graph's edges as index pairs with duplicates and
+// self loops removed and edges that close a cycle reversed. Back edges are
+// found with a depth-first search started from each node in board order.
+func acyclicEdges(g *boardgraph.Graph, index map[string]int) [][2]int {
+	adjacent := make([][]int, len(g.Nodes))
+	seen := make(map[[2]int]bool, len(g.Edges))
+	for _, edge := range g.Edges {
+		pair := [2]int{index[edge.Source], index[edge.Target]}
+		if pair[0] == pair[1] || seen[pair] {
+			continue
+		}
+		seen[pair] = true
+		adjacent[pair[0]] = append(adjacent[pair[0]], pair[1])
+	}
+
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	state := make([]int, len(g.Nodes))
+	var edges [][2]int
+	added := make(map[[2]int]bool, len(seen))
+	add := func(pair [2]int) {
+		if !added[pair] {
+			added[pair] = true
+			edges = append(edges, pair)
+		}
+	}
+
+	var visit func(v int)
+	visit = func(v int) {
+		state[v] = onStack
+		for _, w := range adjacent[v] {
+			switch state[w] {
+			case onStack:
+				add([2]int{w, v})
+			case unvisited:
+				add([2]int{v, w})
+				visit(w)
+			default:
+				add([2]int{v, w})
+			}
+		}
+		state[v] = done
+	}
+	for v := range adjacent {
+		if state[v] == unvisited {
+			visit(v)
+		}
+	}
+
+	return edges
+}
+
+// longestPathLevels puts every node one level after its furthest predecessor
+func longestPathLevels(count int, edges [][2]int) []int {
+	incoming := make([]int, count)
+	outgoing := make([][]int, count)
+	for _, edge := range edges {
+		outgoing[edge[0]] = append(outgoing[edge[0]], edge[1])
+		incoming[edge[1]]++
+	}
+
+	levels := make([]int, count)
+	queue := make([]int, 0, count)
+	for v := 0; v < count; v++ {
+		if incoming[v] == 0 {
+			queue = append(queue, v)
+		}
+	}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, w := range outgoing[v] {
+			if levels[v]+1 > levels[w] {
+				levels[w] = levels[v] + 1
+			}
+			incoming[w]--
+			if incoming[w] == 0 {
+				queue = append(queue, w)
+			}
+		}
+	}
+
+	return levels
+}
+
+// orderLayers reorders each level by the average position of its
+// neighbours in the previous level, sweeping down and back up
+func orderLayers(vertices []*layeredVertex, layers [][]int) {
+	position := make([]float64, len(vertices))
+	record := func(layer []int) {
+		for i, v := range layer {
+			position[v] = float64(i)
+		}
+	}
+	for _, layer := range layers {
+		record(layer)
+	}
+
+	for sweep := 0; sweep < orderingSweeps; sweep++ {
+		down := sweep%2 == 0
+		for step := 1; step < len(layers); step++ {
+			level := step
+			if !down {
+				level = len(layers) - 1 - step
+			}
+
+			layer := layers[level]
+			barycenter := make(map[int]float64, len(layer))
+			for _, v := range layer {
+				neighbours := vertices[v].preds
+				if !down {
+					neighbours = vertices[v].succs
+				}
+				barycenter[v] = position[v]
+				if len(neighbours) > 0 {
+					sum := 0.0
+					for _, n := range neighbours {
+						sum += position[n]
+					}
+					barycenter[v] = sum / float64(len(neighbours))
+				}
+			}
+
+			sort.SliceStable(layer, func(i, j int) bool {
+				return barycenter[layer[i]] < barycenter[layer[j]]
+			})
+			record(layer)
+		}
+	}
+}
+
+// placeLayers assigns vertical centres: levels start stacked, then each
+// vertex is repeatedly moved towards the average of its neighbours while
+// keeping its order and spacing within the level
+func placeLayers(vertices []*layeredVertex, layers [][]int) {
+	for _, layer := range layers {
+		stack(vertices, layer, nil)
+	}
+
+	for sweep := 0; sweep < placementSweeps; sweep++ {
+		down := sweep%2 == 0
+		for step := 1; step < len(layers); step++ {
+			level := step
+			if !down {
+				level = len(layers) - 1 - step
+			}
+
+			layer := layers[level]
+			desired := make([]float64, len(layer))
+			for i, v := range layer {
+				neighbours := vertices[v].preds
+				if !down {
+					neighbours = vertices[v].succs
+				}
+				desired[i] = vertices[v].center
+				if len(neighbours) > 0 {
+					sum := 0.0
+					for _, n := range neighbours {
+						sum += vertices[n].center
+					}
+					desired[i] = sum / float64(len(neighbours))
+				}
+			}
+			stack(vertices, layer, desired)
+		}
+	}
+}
+
+// stack places a level's vertices in order without overlaps, as close to
+// their desired centres as possible. With no desired centres they are packed
+// from zero.
+func stack(vertices []*layeredVertex, layer []int, desired []float64) {
+	next := math.Inf(-1)
+	if desired == nil {
+		next = 0
+	}
+
+	displacement := 0.0
+	for i, v := range layer {
+		vertex := vertices[v]
+		top := next
+		if desired != nil {
+			top = math.Max(desired[i]-vertex.height/2, next)
+			displacement += top + vertex.height/2 - desired[i]
+		}
+		vertex.center = top + vertex.height/2
+		next = top + vertex.height + SiblingGap
+	}
+
+	// Pushing vertices apart only moves them down; share the difference so
+	// the level stays centred on where its neighbours want it
+	if desired != nil && len(layer) > 0 {
+		shift := displacement / float64(len(layer))
+		for _, v := range layer {
+			vertices[v].center -= shift
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Package boardlayout computes node positions for boards whose nodes arrive
+// without sensible ones, such as imported outlines and AI-generated ideas.
+// Each algorithm implements Algorithm and registers itself by name, like the
+// formats in boardexport. Layouts flow left to right, matching the editor's
+// default edge handles, and use the node sizes saved by the client when known.
+package boardlayout
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"saas-server/models"
+	"saas-server/pkg/boardgraph"
+)
+
+// ErrUnknownAlgorithm is returned by Lookup for unregistered algorithm names
+var ErrUnknownAlgorithm = errors.New("unknown layout algorithm")
+
+// Spacing shared by the algorithms, in canvas pixels
+const (
+	// DefaultNodeWidth and DefaultNodeHeight are used for nodes whose
+	// rendered size was never saved
+	DefaultNodeWidth  = 220
+	DefaultNodeHeight = 80
+	// LevelGap separates the columns of consecutive levels
+	LevelGap = 100
+	// SiblingGap separates nodes within a level
+	SiblingGap = 30
+	// TreeGap separates the trees of a forest
+	TreeGap = 80
+)
+
+// Position is the top-left corner of a node, as stored in React Flow's "position"
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Algorithm computes a position for every node of a graph
+type Algorithm interface {
+	// Name is the value used to select the algorithm, e.g. "tree"
+	Name() string
+	// Layout returns the new position of each node keyed by node ID
+	Layout(g *boardgraph.Graph) map[string]Position
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Algorithm)
+)
+
+// Register makes an algorithm available to Lookup, replacing any algorithm with the same name
+func Register(algorithm Algorithm) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[algorithm.Name()] = algorithm
+}
+
+// Lookup returns the algorithm registered under name
+func Lookup(name string) (Algorithm, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	algorithm, ok := registry[name]
+	if !ok {
+		return nil, ErrUnknownAlgorithm
+	}
+	return algorithm, nil
+}
+
+// Names returns the names of all registered algorithms in alphabetical order
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(tidyTreeLayout{})
+	Register(radialLayout{})
+	Register(layeredLayout{})
+}
+
+// Apply returns a copy of data with the "position" of every node in positions
+// replaced. React Flow's cached "positionAbsolute" is updated too when
+// present. Nodes without a new position and all other fields are unchanged.
+func Apply(data models.BoardData, positions map[string]Position) (models.BoardData, error) {
+	result := models.BoardData{
+		Nodes: make([]json.RawMessage, 0, len(data.Nodes)),
+		Edges: data.Edges,
+	}
+
+	for i, raw := range data.Nodes {
+		var node map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &node); err != nil {
+			return data, fmt.Errorf("node %d: %w", i, err)
+		}
+
+		var id string
+		if err := json.Unmarshal(node["id"], &id); err != nil {
+			result.Nodes = append(result.Nodes, raw)
+			continue
+		}
+
+		position, ok := positions[id]
+		if !ok {
+			result.Nodes = append(result.Nodes, raw)
+			continue
+		}
+
+		encoded, err := json.Marshal(position)
+		if err != nil {
+			return data, err
+		}
+		node["position"] = encoded
+		if _, ok := node["positionAbsolute"]; ok {
+			node["positionAbsolute"] = encoded
+		}
+
+		updated, err := json.Marshal(node)
+		if err != nil {
+			return data, err
+		}
+		result.Nodes = append(result.Nodes, updated)
+	}
+
+	return result, nil
+}
+
+// NodeSize returns a node's saved size, or DefaultNodeWidth and
+// DefaultNodeHeight for the dimensions it has not been measured in
+func NodeSize(node *boardgraph.Node) (float64, float64) {
+	width, height := node.Width, node.Height
+	if width <= 0 {
+		width = DefaultNodeWidth
+	}
+	if height <= 0 {
+		height = DefaultNodeHeight
+	}
+	return width, height
+}
+
+// columnOffsets returns the x coordinate of each level given the widest node
+// in each, so wide nodes never overlap the next column
+func columnOffsets(widths []float64) []float64 {
+	offsets := make([]float64, len(widths))
+	x := 0.0
+	for level, width := range widths {
+		offsets[level] = x
+		x += width + LevelGap
+	}
+	return offsets
+}
+
+// growWidths records width as the widest node seen at level
+func growWidths(widths []float64, level int, width float64) []float64 {
+	for len(widths) <= level {
+		widths = append(widths, 0)
+	}
+	if width > widths[level] {
+		widths[level] = width
+	}
+	return widths
+}
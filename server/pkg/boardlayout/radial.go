@@ -0,0 +1,93 @@
+package boardlayout
+
+import (
+	"math"
+
+	"saas-server/pkg/boardgraph"
+)
+
+// radialLayout arranges each tree as a mind map: the root sits in the middle
+// and every level is a ring around it. A subtree gets a wedge of its parent's
+// wedge in proportion to its number of leaves, so branches do not cross.
+// Trees of the forest are placed side by side.
+type radialLayout struct{}
+
+func (radialLayout) Name() string { return "radial" }
+
+func (radialLayout) Layout(g *boardgraph.Graph) map[string]Position {
+	positions := make(map[string]Position, len(g.Nodes))
+
+	left := 0.0
+	for _, tree := range g.Forest() {
+		leaves := make(map[*boardgraph.Tree]int)
+		countLeaves(tree, leaves)
+
+		radii, largest := ringRadii(tree)
+		extent := radii[len(radii)-1] + largest/2
+
+		center := left + extent
+		placeRadial(positions, tree, leaves, radii, center, extent, 0, 2*math.Pi, 0)
+		left = center + extent + TreeGap
+	}
+
+	return positions
+}
+
+// countLeaves records the number of leaves under every subtree
+func countLeaves(tree *boardgraph.Tree, leaves map[*boardgraph.Tree]int) int {
+	if len(tree.Children) == 0 {
+		leaves[tree] = 1
+		return 1
+	}
+
+	count := 0
+	for _, child := range tree.Children {
+		count += countLeaves(child, leaves)
+	}
+	leaves[tree] = count
+	return count
+}
+
+// ringRadii returns the radius of each level of a tree, and the largest node
+// dimension. Rings are at least one column apart and large enough for the
+// nodes on them to fit around the circle.
+func ringRadii(tree *boardgraph.Tree) ([]float64, float64) {
+	var counts []int
+	largest := 0.0
+	boardgraph.Walk([]*boardgraph.Tree{tree}, func(t *boardgraph.Tree, depth int) error {
+		for len(counts) <= depth {
+			counts = append(counts, 0)
+		}
+		counts[depth]++
+		width, height := NodeSize(t.Node)
+		largest = math.Max(largest, math.Max(width, height))
+		return nil
+	})
+
+	step := largest + LevelGap
+	arc := largest + SiblingGap
+
+	radii := make([]float64, len(counts))
+	for depth := 1; depth < len(counts); depth++ {
+		radii[depth] = math.Max(radii[depth-1]+step, float64(counts[depth])*arc/(2*math.Pi))
+	}
+	return radii, largest
+}
+
+// placeRadial positions a subtree whose root is centred in the middle of the
+// wedge from start to end, at the radius of its depth
+func placeRadial(positions map[string]Position, tree *boardgraph.Tree, leaves map[*boardgraph.Tree]int, radii []float64, cx, cy, start, end float64, depth int) {
+	angle := (start + end) / 2
+	x := cx + radii[depth]*math.Cos(angle)
+	y := cy + radii[depth]*math.Sin(angle)
+
+	width, height := NodeSize(tree.Node)
+	positions[tree.Node.ID] = Position{X: x - width/2, Y: y - height/2}
+
+	total := float64(leaves[tree])
+	for _, child := range tree.Children {
+		share := (end - start) * float64(leaves[child]) / total
+		placeRadial(positions, child, leaves, radii, cx, cy, start, start+share, depth+1)
+		start += share
+	}
+}
@@ -0,0 +1,138 @@
+package boardlayout
+
+import (
+	"math"
+
+	"saas-server/pkg/boardgraph"
+)
+
+// tidyTreeLayout arranges the board as a forest of tidy trees in the spirit
+// of Reingold and Tilford: each level is a column, subtrees are packed as
+// closely as their contours allow, and parents are centred on their
+// children. Trees of the forest are stacked from top to bottom.
+type tidyTreeLayout struct{}
+
+func (tidyTreeLayout) Name() string { return "tree" }
+
+// span is the vertical extent of a subtree at one level, relative to the
+// centre of the subtree's root
+type span struct {
+	top, bottom float64
+}
+
+// tidyNode is the packed layout of a subtree
+type tidyNode struct {
+	// offset is the distance from the parent's centre to this node's centre
+	offset   float64
+	contour  []span
+	children []*tidyNode
+}
+
+func (tidyTreeLayout) Layout(g *boardgraph.Graph) map[string]Position {
+	forest := g.Forest()
+
+	var widths []float64
+	boardgraph.Walk(forest, func(tree *boardgraph.Tree, depth int) error {
+		width, _ := NodeSize(tree.Node)
+		widths = growWidths(widths, depth, width)
+		return nil
+	})
+	columns := columnOffsets(widths)
+
+	positions := make(map[string]Position, len(g.Nodes))
+	top := 0.0
+	for _, tree := range forest {
+		packed := packTree(tree)
+
+		extent := packed.contour[0]
+		for _, level := range packed.contour[1:] {
+			extent.top = math.Min(extent.top, level.top)
+			extent.bottom = math.Max(extent.bottom, level.bottom)
+		}
+
+		center := top - extent.top
+		placeTree(positions, columns, tree, packed, center, 0)
+		top = center + extent.bottom + TreeGap
+	}
+
+	return positions
+}
+
+// packTree lays out a subtree relative to its root. Each child subtree is
+// pushed down just far enough to clear the contour of its older siblings.
+func packTree(tree *boardgraph.Tree) *tidyNode {
+	_, height := NodeSize(tree.Node)
+	own := span{top: -height / 2, bottom: height / 2}
+
+	packed := &tidyNode{}
+	if len(tree.Children) == 0 {
+		packed.contour = []span{own}
+		return packed
+	}
+
+	// Child centres and the merged contour are relative to the first child's centre
+	centers := make([]float64, len(tree.Children))
+	var merged []span
+	for i, child := range tree.Children {
+		childPacked := packTree(child)
+		packed.children = append(packed.children, childPacked)
+
+		shift := 0.0
+		if i > 0 {
+			shift = math.Inf(-1)
+			for level := 0; level < len(merged) && level < len(childPacked.contour); level++ {
+				shift = math.Max(shift, merged[level].bottom+SiblingGap-childPacked.contour[level].top)
+			}
+		}
+		centers[i] = shift
+		merged = mergeContours(merged, childPacked.contour, shift)
+	}
+
+	middle := (centers[0] + centers[len(centers)-1]) / 2
+	for i, childPacked := range packed.children {
+		childPacked.offset = centers[i] - middle
+	}
+
+	packed.contour = make([]span, 0, len(merged)+1)
+	packed.contour = append(packed.contour, own)
+	for _, level := range merged {
+		packed.contour = append(packed.contour, span{top: level.top - middle, bottom: level.bottom - middle})
+	}
+
+	return packed
+}
+
+// mergeContours combines a contour with another one shifted down by shift
+func mergeContours(contour, other []span, shift float64) []span {
+	length := len(contour)
+	if len(other) > length {
+		length = len(other)
+	}
+
+	merged := make([]span, length)
+	for level := range merged {
+		switch {
+		case level >= len(other):
+			merged[level] = contour[level]
+		case level >= len(contour):
+			merged[level] = span{top: other[level].top + shift, bottom: other[level].bottom + shift}
+		default:
+			merged[level] = span{
+				top:    math.Min(contour[level].top, other[level].top+shift),
+				bottom: math.Max(contour[level].bottom, other[level].bottom+shift),
+			}
+		}
+	}
+	return merged
+}
+
+// placeTree converts a packed subtree into absolute positions
+func placeTree(positions map[string]Position, columns []float64, tree *boardgraph.Tree, packed *tidyNode, center float64, depth int) {
+	_, height := NodeSize(tree.Node)
+	positions[tree.Node.ID] = Position{X: columns[depth], Y: center - height/2}
+
+	for i, child := range tree.Children {
+		childPacked := packed.children[i]
+		placeTree(positions, columns, child, childPacked, center+childPacked.offset, depth+1)
+	}
+}
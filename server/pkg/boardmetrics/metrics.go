@@ -0,0 +1,215 @@
+// Package boardmetrics measures the structure of a board's graph: how deep
+// and bushy its hierarchy is, which nodes are disconnected, and where ideas
+// loop back on themselves. The summary is cached on the board row so board
+// lists can show it without loading every board's data.
+package boardmetrics
+
+import (
+	"math"
+	"sort"
+
+	"saas-server/models"
+	"saas-server/pkg/boardgraph"
+)
+
+// MostConnectedLimit is the number of nodes reported by MostConnected
+const MostConnectedLimit = 5
+
+// Report is the full set of metrics for one board
+type Report struct {
+	models.BoardMetrics
+	// Orphans are the IDs of nodes without any edge, in board order
+	Orphans []string
+	// MostConnected are the nodes with the most edges, busiest first
+	MostConnected []models.BoardNodeDegree
+}
+
+// Compute measures a graph. Self loops count towards a node's degree and as
+// a cycle but do not make a node a parent or a child.
+func Compute(g *boardgraph.Graph) *Report {
+	index := make(map[string]int, len(g.Nodes))
+	for i, node := range g.Nodes {
+		index[node.ID] = i
+	}
+
+	degree := make([]int, len(g.Nodes))
+	incoming := make([]int, len(g.Nodes))
+	outgoing := make([][]int, len(g.Nodes))
+	selfLoop := make([]bool, len(g.Nodes))
+	components := newUnionFind(len(g.Nodes))
+	for _, edge := range g.Edges {
+		source, target := index[edge.Source], index[edge.Target]
+		degree[source]++
+		degree[target]++
+		if source == target {
+			selfLoop[source] = true
+			continue
+		}
+		incoming[target]++
+		outgoing[source] = append(outgoing[source], target)
+		components.union(source, target)
+	}
+
+	report := &Report{
+		BoardMetrics: models.BoardMetrics{
+			Depth:           depth(g),
+			BranchingFactor: branchingFactor(outgoing),
+			Components:      components.count,
+			Cycles:          countCycles(outgoing, selfLoop),
+		},
+		Orphans:       []string{},
+		MostConnected: []models.BoardNodeDegree{},
+	}
+
+	for i, node := range g.Nodes {
+		switch {
+		case degree[i] == 0:
+			report.Orphans = append(report.Orphans, node.ID)
+		case incoming[i] > 0 && len(outgoing[i]) == 0:
+			report.LeafCount++
+		}
+	}
+	report.OrphanCount = len(report.Orphans)
+
+	busiest := make([]int, 0, len(g.Nodes))
+	for i := range g.Nodes {
+		if degree[i] > 0 {
+			busiest = append(busiest, i)
+		}
+	}
+	sort.SliceStable(busiest, func(a, b int) bool {
+		return degree[busiest[a]] > degree[busiest[b]]
+	})
+	if len(busiest) > MostConnectedLimit {
+		busiest = busiest[:MostConnectedLimit]
+	}
+	for _, i := range busiest {
+		report.MostConnected = append(report.MostConnected, models.BoardNodeDegree{
+			ID:     g.Nodes[i].ID,
+			Label:  g.Nodes[i].Title(),
+			Degree: degree[i],
+		})
+	}
+
+	return report
+}
+
+// depth is the number of levels in the deepest tree of the board's forest,
+// so a lone node has depth 1 and an empty board depth 0
+func depth(g *boardgraph.Graph) int {
+	deepest := 0
+	boardgraph.Walk(g.Forest(), func(tree *boardgraph.Tree, level int) error {
+		if level+1 > deepest {
+			deepest = level + 1
+		}
+		return nil
+	})
+	return deepest
+}
+
+// branchingFactor is the average number of children of nodes that have any,
+// rounded to two decimal places
+func branchingFactor(outgoing [][]int) float64 {
+	parents, children := 0, 0
+	for _, targets := range outgoing {
+		if len(targets) > 0 {
+			parents++
+			children += len(targets)
+		}
+	}
+	if parents == 0 {
+		return 0
+	}
+	return math.Round(float64(children)/float64(parents)*100) / 100
+}
+
+// countCycles returns the number of strongly connected components that
+// contain a cycle, that is groups of two or more nodes that can all reach
+// each other plus single nodes with an edge to themselves. It uses Tarjan's
+// algorithm.
+func countCycles(outgoing [][]int, selfLoop []bool) int {
+	const unvisited = -1
+
+	order := make([]int, len(outgoing))
+	low := make([]int, len(outgoing))
+	onStack := make([]bool, len(outgoing))
+	for v := range order {
+		order[v] = unvisited
+	}
+
+	var stack []int
+	next, cycles := 0, 0
+
+	var visit func(v int)
+	visit = func(v int) {
+		order[v], low[v] = next, next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range outgoing[v] {
+			if order[w] == unvisited {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], order[w])
+			}
+		}
+
+		if low[v] != order[v] {
+			return
+		}
+
+		size := 0
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			size++
+			if w == v {
+				break
+			}
+		}
+		if size > 1 || selfLoop[v] {
+			cycles++
+		}
+	}
+
+	for v := range outgoing {
+		if order[v] == unvisited {
+			visit(v)
+		}
+	}
+
+	return cycles
+}
+
+// unionFind tracks the weakly connected components of the graph
+type unionFind struct {
+	parent []int
+	count  int
+}
+
+func newUnionFind(size int) *unionFind {
+	u := &unionFind{parent: make([]int, size), count: size}
+	for i := range u.parent {
+		u.parent[i] = i
+	}
+	return u
+}
+
+func (u *unionFind) find(v int) int {
+	for u.parent[v] != v {
+		u.parent[v] = u.parent[u.parent[v]]
+		v = u.parent[v]
+	}
+	return v
+}
+
+func (u *unionFind) union(a, b int) {
+	rootA, rootB := u.find(a), u.find(b)
+	if rootA != rootB {
+		u.parent[rootA] = rootB
+		u.count--
+	}
+}
@@ -0,0 +1,160 @@
+// Package boardpatch applies incremental add, update and remove operations
+// to the nodes and edges stored in a board's data
+package boardpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"saas-server/models"
+)
+
+// ErrInvalidPatch is wrapped by every error caused by the content of a patch
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// Apply returns a copy of data with the patch applied. Removals are applied
+// first, then updates, then additions, so an element can be replaced by
+// removing and adding it in the same patch. The original data is not modified.
+func Apply(data models.BoardData, patch models.BoardPatchRequest) (models.BoardData, error) {
+	nodes, err := applyElements("node", data.Nodes, patch.Nodes)
+	if err != nil {
+		return data, err
+	}
+
+	edges, err := applyElements("edge", data.Edges, patch.Edges)
+	if err != nil {
+		return data, err
+	}
+
+	return models.BoardData{Nodes: nodes, Edges: edges}, nil
+}
+
+// ElementID returns the "id" field of a node or edge
+func ElementID(raw json.RawMessage) (string, error) {
+	var element struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &element); err != nil {
+		return "", err
+	}
+	return element.ID, nil
+}
+
+// Find returns the node or edge with the given ID. Elements that are not
+// valid objects are skipped.
+func Find(elements []json.RawMessage, id string) (json.RawMessage, bool) {
+	for _, element := range elements {
+		if elementID, err := ElementID(element); err == nil && elementID == id {
+			return element, true
+		}
+	}
+	return nil, false
+}
+
+// applyElements applies a single element patch to a list of nodes or edges
+func applyElements(kind string, elements []json.RawMessage, patch models.BoardElementPatch) ([]json.RawMessage, error) {
+	removed := make(map[string]bool, len(patch.Remove))
+	for _, id := range patch.Remove {
+		removed[id] = true
+	}
+
+	result := make([]json.RawMessage, 0, len(elements)+len(patch.Add))
+	index := make(map[string]int, len(elements))
+	for _, element := range elements {
+		id, err := ElementID(element)
+		if err != nil {
+			return nil, fmt.Errorf("stored %s is not a valid object: %v", kind, err)
+		}
+		if removed[id] {
+			continue
+		}
+		index[id] = len(result)
+		result = append(result, element)
+	}
+
+	for _, update := range patch.Update {
+		id, err := ElementID(update)
+		if err != nil || id == "" {
+			return nil, fmt.Errorf("%w: %s update must be an object with an id", ErrInvalidPatch, kind)
+		}
+		position, ok := index[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s %q does not exist", ErrInvalidPatch, kind, id)
+		}
+		merged, err := MergePatch(result[position], update)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s %q: %v", ErrInvalidPatch, kind, id, err)
+		}
+		result[position] = merged
+	}
+
+	for _, addition := range patch.Add {
+		id, err := ElementID(addition)
+		if err != nil || id == "" {
+			return nil, fmt.Errorf("%w: added %s must be an object with an id", ErrInvalidPatch, kind)
+		}
+		if _, exists := index[id]; exists {
+			return nil, fmt.Errorf("%w: %s %q already exists", ErrInvalidPatch, kind, id)
+		}
+		index[id] = len(result)
+		result = append(result, addition)
+	}
+
+	return result, nil
+}
+
+// MergePatch applies an RFC 7386 JSON Merge Patch to a JSON object
+func MergePatch(target, patch json.RawMessage) (json.RawMessage, error) {
+	targetValue, err := decodeValue(target)
+	if err != nil {
+		return nil, err
+	}
+	patchValue, err := decodeValue(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergeValue(targetValue, patchValue))
+}
+
+// decodeValue decodes a single JSON value, keeping numbers as json.Number so
+// integers too large for a float64 are written back unchanged
+func decodeValue(raw json.RawMessage) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("%w: unexpected data after JSON value", ErrInvalidPatch)
+	}
+	return value, nil
+}
+
+// mergeValue recursively merges patch into target following RFC 7386
+func mergeValue(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergeValue(targetObject[key], value)
+	}
+
+	return targetObject
+}
@@ -0,0 +1,265 @@
+// Package boardvalidate checks the React Flow nodes and edges in a board's
+// data before they are stored, so malformed elements are rejected with
+// field-level errors instead of breaking the editor when the board is loaded
+package boardvalidate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"saas-server/models"
+)
+
+const (
+	// MaxLabelLength is the longest node or edge label accepted, in characters
+	MaxLabelLength = 500
+	// MaxContentLength is the longest node content text accepted, in characters
+	MaxContentLength = 100000
+	// maxReportedErrors caps the number of field errors returned for one board
+	maxReportedErrors = 50
+)
+
+// Error lists every problem found in a board's data
+type Error struct {
+	Fields []models.BoardFieldError
+}
+
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return "invalid board data"
+	}
+	first := e.Fields[0]
+	if len(e.Fields) == 1 {
+		return fmt.Sprintf("invalid board data: %s %s", first.Field, first.Message)
+	}
+	return fmt.Sprintf("invalid board data: %s %s (and %d more)", first.Field, first.Message, len(e.Fields)-1)
+}
+
+// Result is board data that passed validation
+type Result struct {
+	Data models.BoardData
+	// DroppedEdges holds the IDs of edges removed because their source or
+	// target node does not exist
+	DroppedEdges []string
+}
+
+// Validate checks that every node has a unique string id, a type, a numeric
+// position and a string data.label, that labels and content fit the size
+// limits, and that every edge has a unique id and string source and target.
+// Edges pointing at missing nodes are dropped rather than rejected, since
+// they are usually left behind by a node deletion. Missing node or edge
+// lists are normalized to empty ones.
+func Validate(data models.BoardData) (*Result, error) {
+	v := &validator{}
+	result := &Result{Data: models.BoardData{
+		Nodes: make([]json.RawMessage, 0, len(data.Nodes)),
+		Edges: make([]json.RawMessage, 0, len(data.Edges)),
+	}}
+
+	nodeIDs := make(map[string]int, len(data.Nodes))
+	for i, raw := range data.Nodes {
+		id, ok := v.node(fmt.Sprintf("nodes[%d]", i), raw)
+		if !ok {
+			continue
+		}
+		if first, exists := nodeIDs[id]; exists {
+			v.add(fmt.Sprintf("nodes[%d].id", i), fmt.Sprintf("duplicates the id of nodes[%d]", first))
+			continue
+		}
+		nodeIDs[id] = i
+		result.Data.Nodes = append(result.Data.Nodes, raw)
+	}
+
+	edgeIDs := make(map[string]int, len(data.Edges))
+	for i, raw := range data.Edges {
+		edge, ok := v.edge(fmt.Sprintf("edges[%d]", i), raw)
+		if !ok {
+			continue
+		}
+		if first, exists := edgeIDs[edge.ID]; exists {
+			v.add(fmt.Sprintf("edges[%d].id", i), fmt.Sprintf("duplicates the id of edges[%d]", first))
+			continue
+		}
+		edgeIDs[edge.ID] = i
+
+		_, hasSource := nodeIDs[edge.Source]
+		_, hasTarget := nodeIDs[edge.Target]
+		if !hasSource || !hasTarget {
+			result.DroppedEdges = append(result.DroppedEdges, edge.ID)
+			continue
+		}
+		result.Data.Edges = append(result.Data.Edges, raw)
+	}
+
+	if len(v.errors) > 0 {
+		return nil, &Error{Fields: v.errors}
+	}
+	return result, nil
+}
+
+// validator collects field errors, keeping at most maxReportedErrors
+type validator struct {
+	errors []models.BoardFieldError
+}
+
+func (v *validator) add(field, message string) {
+	if len(v.errors) < maxReportedErrors {
+		v.errors = append(v.errors, models.BoardFieldError{Field: field, Message: message})
+	}
+}
+
+// node validates a single node and returns its ID
+func (v *validator) node(path string, raw json.RawMessage) (string, bool) {
+	var node map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &node); err != nil || node == nil {
+		v.add(path, "must be an object")
+		return "", false
+	}
+
+	valid := true
+	id, ok := v.requiredString(path+".id", node["id"])
+	valid = valid && ok
+	_, ok = v.requiredString(path+".type", node["type"])
+	valid = valid && ok
+	valid = v.position(path+".position", node["position"]) && valid
+	valid = v.nodeData(path+".data", node["data"]) && valid
+
+	return id, valid
+}
+
+// position checks that a position is an object with numeric x and y
+func (v *validator) position(path string, raw json.RawMessage) bool {
+	if isMissing(raw) {
+		v.add(path, "is required")
+		return false
+	}
+
+	var position map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &position); err != nil || position == nil {
+		v.add(path, "must be an object")
+		return false
+	}
+
+	valid := true
+	for _, axis := range []string{"x", "y"} {
+		var value float64
+		if isMissing(position[axis]) || json.Unmarshal(position[axis], &value) != nil {
+			v.add(path+"."+axis, "must be a number")
+			valid = false
+		}
+	}
+	return valid
+}
+
+// nodeData checks a node's label and the size of its content
+func (v *validator) nodeData(path string, raw json.RawMessage) bool {
+	if isMissing(raw) {
+		v.add(path, "is required")
+		return false
+	}
+
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &data); err != nil || data == nil {
+		v.add(path, "must be an object")
+		return false
+	}
+
+	valid := true
+	var label string
+	if isMissing(data["label"]) || json.Unmarshal(data["label"], &label) != nil {
+		v.add(path+".label", "must be a string")
+		valid = false
+	} else if utf8.RuneCountInString(label) > MaxLabelLength {
+		v.add(path+".label", fmt.Sprintf("must be at most %d characters", MaxLabelLength))
+		valid = false
+	}
+
+	if content, field := contentText(data["content"]); utf8.RuneCountInString(content) > MaxContentLength {
+		v.add(path+".content"+field, fmt.Sprintf("must be at most %d characters", MaxContentLength))
+		valid = false
+	}
+
+	return valid
+}
+
+// edgeFields are the edge fields that are validated
+type edgeFields struct {
+	ID     string
+	Source string
+	Target string
+}
+
+// edge validates a single edge
+func (v *validator) edge(path string, raw json.RawMessage) (edgeFields, bool) {
+	var edge map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &edge); err != nil || edge == nil {
+		v.add(path, "must be an object")
+		return edgeFields{}, false
+	}
+
+	var fields edgeFields
+	var ok bool
+	valid := true
+	fields.ID, ok = v.requiredString(path+".id", edge["id"])
+	valid = valid && ok
+	fields.Source, ok = v.requiredString(path+".source", edge["source"])
+	valid = valid && ok
+	fields.Target, ok = v.requiredString(path+".target", edge["target"])
+	valid = valid && ok
+
+	var label string
+	if !isMissing(edge["label"]) && json.Unmarshal(edge["label"], &label) == nil &&
+		utf8.RuneCountInString(label) > MaxLabelLength {
+		v.add(path+".label", fmt.Sprintf("must be at most %d characters", MaxLabelLength))
+		valid = false
+	}
+
+	return fields, valid
+}
+
+// requiredString reads a non-empty string field
+func (v *validator) requiredString(path string, raw json.RawMessage) (string, bool) {
+	if isMissing(raw) {
+		v.add(path, "is required")
+		return "", false
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		v.add(path, "must be a string")
+		return "", false
+	}
+	if strings.TrimSpace(value) == "" {
+		v.add(path, "must not be empty")
+		return "", false
+	}
+	return value, true
+}
+
+// contentText returns the text of a node's content, which is either a string
+// or an object with a "text" field, and the subfield it was read from
+func contentText(raw json.RawMessage) (string, string) {
+	if isMissing(raw) {
+		return "", ""
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, ""
+	}
+
+	var content struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &content); err == nil {
+		return content.Text, ".text"
+	}
+	return "", ""
+}
+
+// isMissing reports whether a field is absent or null
+func isMissing(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
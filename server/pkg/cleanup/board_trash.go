@@ -0,0 +1,46 @@
+package cleanup
+
+import (
+	"log"
+	"time"
+
+	"saas-server/database"
+)
+
+// BoardTrashPurgeService permanently deletes boards that have been in the
+// trash for longer than the retention period
+type BoardTrashPurgeService struct {
+	boards    *database.BoardDB
+	retention time.Duration
+}
+
+// NewBoardTrashPurgeService creates a new instance of BoardTrashPurgeService
+func NewBoardTrashPurgeService(boards *database.BoardDB, retention time.Duration) *BoardTrashPurgeService {
+	return &BoardTrashPurgeService{
+		boards:    boards,
+		retention: retention,
+	}
+}
+
+// StartPurgeJob runs a purge straight away and then once every hour
+func (s *BoardTrashPurgeService) StartPurgeJob() {
+	ticker := time.NewTicker(1 * time.Hour)
+	go func() {
+		s.purge()
+		for range ticker.C {
+			s.purge()
+		}
+	}()
+}
+
+// purge deletes boards trashed before the retention cutoff
+func (s *BoardTrashPurgeService) purge() {
+	deleted, err := s.boards.PurgeTrashedBoards(time.Now().Add(-s.retention))
+	if err != nil {
+		log.Printf("Error purging trashed boards: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Purged %d boards from the trash", deleted)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"html"
 	"log"
 	"net/http"
 	"os"
@@ -143,3 +144,31 @@ func SendVerificationEmail(to string, verificationURL string) error {
 
 	return SendEmail(to, subject, htmlContent)
 }
+
+// SendBoardInviteEmail notifies a user that a board has been shared with them
+func SendBoardInviteEmail(to, inviterName, boardName, role, boardURL string) error {
+	subject := inviterName + " shared a board with you"
+
+	htmlContent := `
+	<h2>` + html.EscapeString(inviterName) + ` shared a board with you</h2>
+	<p>You now have <strong>` + html.EscapeString(role) + `</strong> access to the board "` + html.EscapeString(boardName) + `".</p>
+	<p><a href="` + boardURL + `">Open the board</a></p>
+	<p>If you weren't expecting this, you can safely ignore this email.</p>
+	`
+
+	return SendEmail(to, subject, htmlContent)
+}
+
+// SendCommentMentionEmail notifies a user that they were @mentioned in a
+// comment on a board node
+func SendCommentMentionEmail(to, authorName, boardName, commentBody, boardURL string) error {
+	subject := authorName + " mentioned you in a comment"
+
+	htmlContent := `
+	<h2>` + html.EscapeString(authorName) + ` mentioned you on "` + html.EscapeString(boardName) + `"</h2>
+	<blockquote>` + html.EscapeString(commentBody) + `</blockquote>
+	<p><a href="` + boardURL + `">Open the discussion</a></p>
+	`
+
+	return SendEmail(to, subject, htmlContent)
+}
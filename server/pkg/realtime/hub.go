@@ -0,0 +1,84 @@
+// Package realtime fans out board editing events and presence information
+// to every session connected to the same board
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Event types sent to connected sessions. EventUpdate announces a version
+// saved outside the live operations, such as a REST edit or a restored
+// revision; sessions reload the board to catch up.
+const (
+	EventHello    = "hello"
+	EventOps      = "ops"
+	EventUpdate   = "update"
+	EventPresence = "presence"
+	EventJoin     = "join"
+	EventLeave    = "leave"
+)
+
+// ErrUnknownSession is returned when a session is not connected to the board
+var ErrUnknownSession = errors.New("session is not connected to this board")
+
+// Event is a message broadcast to the sessions connected to a board
+type Event struct {
+	Type      string          `json:"type"`
+	BoardID   string          `json:"boardId"`
+	SessionID string          `json:"sessionId,omitempty"`
+	UserID    string          `json:"userId,omitempty"`
+	Version   int             `json:"version,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Time      time.Time       `json:"time"`
+}
+
+// Point is a position on the board canvas
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Presence describes a session connected to a board
+type Presence struct {
+	SessionID      string    `json:"sessionId"`
+	UserID         string    `json:"userId"`
+	Name           string    `json:"name"`
+	Cursor         *Point    `json:"cursor,omitempty"`
+	SelectedNodeID string    `json:"selectedNodeId,omitempty"`
+	ConnectedAt    time.Time `json:"connectedAt"`
+	LastSeen       time.Time `json:"lastSeen"`
+}
+
+// PresenceUpdate carries the fields a session may change about itself
+type PresenceUpdate struct {
+	Cursor         *Point `json:"cursor,omitempty"`
+	SelectedNodeID string `json:"selectedNodeId"`
+}
+
+// Subscription is a session's connection to a board
+type Subscription struct {
+	// Events delivers broadcasts for the board; it is closed when the
+	// subscription is cancelled or the session falls too far behind
+	Events <-chan Event
+	// Cancel removes the session from the board and announces its departure
+	Cancel func()
+}
+
+// Hub routes events between the sessions connected to each board.
+// InProcessHub serves a single server instance; an implementation backed by
+// Postgres LISTEN/NOTIFY can satisfy the same interface to fan out across replicas.
+type Hub interface {
+	// Subscribe connects a session to a board and announces it to the others
+	Subscribe(boardID string, presence Presence) Subscription
+	// Publish delivers an event to every session connected to the board
+	Publish(event Event) error
+	// UpdatePresence changes a connected session's cursor or selection and broadcasts it
+	UpdatePresence(boardID, sessionID, userID string, update PresenceUpdate) error
+	// Presence lists the sessions currently connected to a board
+	Presence(boardID string) []Presence
+	// Disconnect closes every session a user has on a board, such as after
+	// their access was revoked
+	Disconnect(boardID, userID string)
+}
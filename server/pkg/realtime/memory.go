@@ -0,0 +1,184 @@
+package realtime
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is how many events may queue for a session before it is
+// considered too slow and disconnected
+const subscriberBuffer = 64
+
+// subscriber is a single session connected to a board
+type subscriber struct {
+	presence Presence
+	events   chan Event
+}
+
+// InProcessHub is a Hub that broadcasts to sessions connected to this process
+type InProcessHub struct {
+	mutex  sync.RWMutex
+	boards map[string]map[string]*subscriber
+}
+
+// NewInProcessHub creates a new InProcessHub
+func NewInProcessHub() *InProcessHub {
+	return &InProcessHub{
+		boards: make(map[string]map[string]*subscriber),
+	}
+}
+
+// Subscribe connects a session to a board and announces it to the others
+func (h *InProcessHub) Subscribe(boardID string, presence Presence) Subscription {
+	now := time.Now()
+	presence.ConnectedAt = now
+	presence.LastSeen = now
+
+	sub := &subscriber{
+		presence: presence,
+		events:   make(chan Event, subscriberBuffer),
+	}
+
+	h.mutex.Lock()
+	sessions, ok := h.boards[boardID]
+	if !ok {
+		sessions = make(map[string]*subscriber)
+		h.boards[boardID] = sessions
+	}
+	sessions[presence.SessionID] = sub
+	h.mutex.Unlock()
+
+	h.broadcastPresence(EventJoin, boardID, presence)
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			if h.remove(boardID, presence.SessionID, sub) != nil {
+				h.broadcastPresence(EventLeave, boardID, presence)
+			}
+		})
+	}
+
+	return Subscription{Events: sub.events, Cancel: cancel}
+}
+
+// Publish delivers an event to every session connected to the board
+func (h *InProcessHub) Publish(event Event) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	h.mutex.RLock()
+	var slow []string
+	for sessionID, sub := range h.boards[event.BoardID] {
+		select {
+		case sub.events <- event:
+		default:
+			slow = append(slow, sessionID)
+		}
+	}
+	h.mutex.RUnlock()
+
+	// Disconnect sessions that stopped reading; they will reconnect and resync
+	for _, sessionID := range slow {
+		log.Printf("[Realtime] Dropping slow session %s on board %s", sessionID, event.BoardID)
+		if sub := h.remove(event.BoardID, sessionID, nil); sub != nil {
+			h.broadcastPresence(EventLeave, event.BoardID, sub.presence)
+		}
+	}
+
+	return nil
+}
+
+// UpdatePresence changes a connected session's cursor or selection and broadcasts it
+func (h *InProcessHub) UpdatePresence(boardID, sessionID, userID string, update PresenceUpdate) error {
+	h.mutex.Lock()
+	sub, ok := h.boards[boardID][sessionID]
+	if !ok || sub.presence.UserID != userID {
+		h.mutex.Unlock()
+		return ErrUnknownSession
+	}
+	sub.presence.Cursor = update.Cursor
+	sub.presence.SelectedNodeID = update.SelectedNodeID
+	sub.presence.LastSeen = time.Now()
+	presence := sub.presence
+	h.mutex.Unlock()
+
+	h.broadcastPresence(EventPresence, boardID, presence)
+	return nil
+}
+
+// Presence lists the sessions currently connected to a board, oldest first
+func (h *InProcessHub) Presence(boardID string) []Presence {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	sessions := h.boards[boardID]
+	result := make([]Presence, 0, len(sessions))
+	for _, sub := range sessions {
+		result = append(result, sub.presence)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ConnectedAt.Before(result[j].ConnectedAt)
+	})
+	return result
+}
+
+// Disconnect closes every session a user has on a board and announces their departure
+func (h *InProcessHub) Disconnect(boardID, userID string) {
+	h.mutex.RLock()
+	var sessionIDs []string
+	for sessionID, sub := range h.boards[boardID] {
+		if sub.presence.UserID == userID {
+			sessionIDs = append(sessionIDs, sessionID)
+		}
+	}
+	h.mutex.RUnlock()
+
+	for _, sessionID := range sessionIDs {
+		if sub := h.remove(boardID, sessionID, nil); sub != nil {
+			h.broadcastPresence(EventLeave, boardID, sub.presence)
+		}
+	}
+}
+
+// remove disconnects a session and closes its channel. If expected is not nil
+// the session is only removed while it still refers to that subscriber.
+// It returns the removed subscriber, or nil if nothing was removed.
+func (h *InProcessHub) remove(boardID, sessionID string, expected *subscriber) *subscriber {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	sessions := h.boards[boardID]
+	sub, ok := sessions[sessionID]
+	if !ok || (expected != nil && sub != expected) {
+		return nil
+	}
+
+	delete(sessions, sessionID)
+	close(sub.events)
+	if len(sessions) == 0 {
+		delete(h.boards, boardID)
+	}
+	return sub
+}
+
+// broadcastPresence publishes a presence change for a session
+func (h *InProcessHub) broadcastPresence(eventType, boardID string, presence Presence) {
+	payload, err := json.Marshal(presence)
+	if err != nil {
+		log.Printf("[Realtime] Error marshaling presence: %v", err)
+		return
+	}
+
+	h.Publish(Event{
+		Type:      eventType,
+		BoardID:   boardID,
+		SessionID: presence.SessionID,
+		UserID:    presence.UserID,
+		Payload:   payload,
+	})
+}